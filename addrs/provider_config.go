@@ -2,16 +2,40 @@ package addrs
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/terraform/tfdiags"
 
 	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// defaultProviderRegistryHost and defaultProviderNamespace are the source
+// values implied for a provider configuration that names only a short type,
+// e.g. "aws", as all provider configurations did before source addresses
+// existed. Config and state written before then are upgraded to this source
+// so that they keep referring to the same provider they always did.
+const (
+	defaultProviderRegistryHost = "registry.terraform.io"
+	defaultProviderNamespace    = "hashicorp"
 )
 
 // ProviderConfig is the address of a provider configuration.
 type ProviderConfig struct {
 	Type string
 
+	// Namespace and Hostname optionally qualify Type with the rest of a
+	// fully-qualified provider source address, e.g. "hashicorp" and
+	// "registry.terraform.io" for "registry.terraform.io/hashicorp/aws".
+	// They are empty for a provider configuration declared the legacy way,
+	// by type name alone; use LegacySource to fill in the implied defaults
+	// before comparing two ProviderConfig values that might mix the two
+	// styles. Populating them lets two modules each declare a provider
+	// called "aws" sourced from different namespaces or registries without
+	// colliding, since String incorporates them into the address.
+	Namespace string
+	Hostname  string
+
 	// If not empty, Alias identifies which non-default (aliased) provider
 	// configuration this address refers to.
 	Alias string
@@ -25,6 +49,47 @@ func NewDefaultProviderConfig(typeName string) ProviderConfig {
 	}
 }
 
+// NewProviderConfig returns the address of the default (un-aliased)
+// configuration for a provider with a fully-qualified source address, such
+// as one declared via an explicit "source" argument in a required_providers
+// block.
+func NewProviderConfig(hostname, namespace, typeName string) ProviderConfig {
+	return ProviderConfig{
+		Hostname:  hostname,
+		Namespace: namespace,
+		Type:      typeName,
+	}
+}
+
+// ProviderConfigForSource returns the address of the default (un-aliased)
+// configuration for a provider declared via a required_providers "source"
+// argument, such as "hashicorp/aws" or "registry.terraform.io/hashicorp/aws".
+// It's the counterpart to NewProviderConfig for callers that only have the
+// source string as written in configuration, rather than its parts already
+// split out.
+func ProviderConfigForSource(source string) (ProviderConfig, error) {
+	hostname, namespace, typeName, err := parseProviderSourceString(source)
+	if err != nil {
+		return ProviderConfig{}, err
+	}
+	return NewProviderConfig(hostname, namespace, typeName), nil
+}
+
+// LegacySource returns the receiver with Namespace and Hostname populated
+// from the implied default registry source if they were not already set.
+// This is the migration path for configuration and state that only ever
+// named a provider by its short type: they're treated as if they'd always
+// explicitly named registry.terraform.io/hashicorp/<type>.
+func (pc ProviderConfig) LegacySource() ProviderConfig {
+	if pc.Namespace == "" {
+		pc.Namespace = defaultProviderNamespace
+	}
+	if pc.Hostname == "" {
+		pc.Hostname = defaultProviderRegistryHost
+	}
+	return pc
+}
+
 // Absolute returns an AbsProviderConfig from the receiver and the given module
 // instance address.
 func (pc ProviderConfig) Absolute(module ModuleInstance) AbsProviderConfig {
@@ -35,11 +100,29 @@ func (pc ProviderConfig) Absolute(module ModuleInstance) AbsProviderConfig {
 }
 
 func (pc ProviderConfig) String() string {
-	if pc.Alias != "" {
-		return fmt.Sprintf("provider.%s.%s", pc.Type, pc.Alias)
+	if pc.Namespace == "" && pc.Hostname == "" {
+		// Legacy form: a provider configuration that only ever named its
+		// type. We keep producing the address format used before source
+		// addresses existed so that existing state and log output don't
+		// change for configurations that don't use them.
+		if pc.Alias != "" {
+			return fmt.Sprintf("provider.%s.%s", pc.Type, pc.Alias)
+		}
+		return "provider." + pc.Type
+	}
+
+	source := pc.Type
+	if pc.Namespace != "" {
+		source = pc.Namespace + "/" + source
+	}
+	if pc.Hostname != "" && pc.Hostname != defaultProviderRegistryHost {
+		source = pc.Hostname + "/" + source
 	}
 
-	return "provider." + pc.Type
+	if pc.Alias != "" {
+		return fmt.Sprintf("provider[%q].%s", source, pc.Alias)
+	}
+	return fmt.Sprintf("provider[%q]", source)
 }
 
 // AbsProviderConfig is the absolute address of a provider configuration
@@ -58,6 +141,8 @@ type AbsProviderConfig struct {
 //     module.bar.provider.aws
 //     module.bar.module.baz.provider.aws.foo
 //     module.foo[1].provider.aws.foo
+//     provider["registry.terraform.io/hashicorp/aws"]
+//     provider["registry.terraform.io/hashicorp/aws"].foo
 //
 // This type of address is used, for example, to record the relationships
 // between resources and provider configurations in the state structure.
@@ -87,13 +172,40 @@ func ParseAbsProviderConfig(traversal hcl.Traversal) (AbsProviderConfig, tfdiags
 		return ret, diags
 	}
 
-	if tt, ok := remain[1].(hcl.TraverseAttr); ok {
-		ret.ProviderConfig.Type = tt.Name
-	} else {
+	switch step := remain[1].(type) {
+	case hcl.TraverseAttr:
+		ret.ProviderConfig.Type = step.Name
+	case hcl.TraverseIndex:
+		// This is the qualified form, e.g. provider["registry.terraform.io/hashicorp/aws"],
+		// used to address a provider by its fully-qualified source rather
+		// than just its short type name.
+		if step.Key.Type() != cty.String {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid provider configuration address",
+				Detail:   "Provider source address must be given as a string.",
+				Subject:  remain[1].SourceRange().Ptr(),
+			})
+			return ret, diags
+		}
+		hostname, namespace, typeName, err := parseProviderSourceString(step.Key.AsString())
+		if err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid provider configuration address",
+				Detail:   fmt.Sprintf("Invalid provider source string: %s.", err),
+				Subject:  remain[1].SourceRange().Ptr(),
+			})
+			return ret, diags
+		}
+		ret.ProviderConfig.Hostname = hostname
+		ret.ProviderConfig.Namespace = namespace
+		ret.ProviderConfig.Type = typeName
+	default:
 		diags = diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Invalid provider configuration address",
-			Detail:   "The prefix \"provider.\" must be followed by a provider type name.",
+			Detail:   "The prefix \"provider.\" must be followed by a provider type name, or \"provider\" followed by a quoted provider source string in brackets.",
 			Subject:  remain[1].SourceRange().Ptr(),
 		})
 		return ret, diags
@@ -116,6 +228,24 @@ func ParseAbsProviderConfig(traversal hcl.Traversal) (AbsProviderConfig, tfdiags
 	return ret, diags
 }
 
+// parseProviderSourceString splits a provider source string of the form
+// "type", "namespace/type", or "hostname/namespace/type" into its parts,
+// as seen in both a qualified provider address traversal and a
+// required_providers "source" argument.
+func parseProviderSourceString(source string) (hostname, namespace, typeName string, err error) {
+	parts := strings.Split(source, "/")
+	switch len(parts) {
+	case 1:
+		return "", "", parts[0], nil
+	case 2:
+		return "", parts[0], parts[1], nil
+	case 3:
+		return parts[0], parts[1], parts[2], nil
+	default:
+		return "", "", "", fmt.Errorf("must have the form \"[hostname/][namespace/]type\"")
+	}
+}
+
 // Inherited returns an address that the receiving configuration address might
 // inherit from in a parent module. The second bool return value indicates if
 // such inheritance is possible, and thus whether the returned address is valid.
@@ -5,12 +5,15 @@ import (
 	"io/ioutil"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
 	"github.com/hashicorp/terraform/configs"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty/convert"
 )
 
 // variableValues gathers the variable values provided in default variables
@@ -30,39 +33,8 @@ func (m *Meta) variableValues() (map[string]backend.UnparsedVariableValue, tfdia
 	var diags tfdiags.Diagnostics
 
 	// Environment variables
-	const envVarPrefix = "TF_VAR_"
-	for _, varStr := range os.Environ() {
-		if !strings.HasPrefix(varStr, envVarPrefix) {
-			continue
-		}
-		eq := strings.Index(varStr, "=")
-		if eq == -1 {
-			// Weird...
-			continue
-		}
-		name := varStr[len(envVarPrefix):eq]
-		rawValue := varStr[eq+1:]
-
-		cv, exists := config[name]
-		if !exists {
-			// For env vars, unlike all other cases, we tolerate and ignore
-			// attempts to set variables that are not declared in the
-			// configuration, since that allows a user to leave certain
-			// variables set permanently in their shell or by an automation
-			// wrapper if they are used across many configurations.
-			continue
-		}
-
-		val, valDiags := cv.ParsingMode.Parse(name, rawValue)
-		diags = diags.Append(valDiags)
-		if valDiags.HasErrors() {
-			continue
-		}
-		ret[name] = &terraform.InputValue{
-			Value:      val,
-			SourceType: terraform.ValueFromEnvVar,
-		}
-	}
+	envDiags := m.loadVariableValuesFromEnv(config, ret)
+	diags = diags.Append(envDiags)
 
 	// We automatically read certain .tfvars and .tfvars.json files if they
 	// are present.
@@ -157,6 +129,103 @@ func (m *Meta) variableValues() (map[string]backend.UnparsedVariableValue, tfdia
 	return ret, diags
 }
 
+// loadVariableValuesFromEnv scans the process environment for TF_VAR_-prefixed
+// variables and parses each one as an HCL expression evaluated against the
+// corresponding variable's declared type, the same way a .tfvars file's
+// attributes are parsed via attr.Expr.Value. This gives environment
+// variables the same expressiveness .tfvars files already have (objects,
+// tuples, and type-converted values) instead of the more limited
+// literal-or-simple-HCL behavior of ParsingMode.Parse.
+//
+// If the TF_VAR_STRICT environment variable is set to a truthy value, a
+// TF_VAR_ naming a variable the configuration doesn't declare is reported as
+// an error instead of being silently ignored, matching how -var and .tfvars
+// files already treat unknown names. The default remains to ignore them, so
+// that users can leave variables set permanently in their shell across
+// configurations that don't all declare the same set of variables.
+func (m *Meta) loadVariableValuesFromEnv(config map[string]*configs.Variable, into terraform.InputValues) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	strict, _ := strconv.ParseBool(os.Getenv("TF_VAR_STRICT"))
+
+	const envVarPrefix = "TF_VAR_"
+	for _, varStr := range os.Environ() {
+		if !strings.HasPrefix(varStr, envVarPrefix) {
+			continue
+		}
+		eq := strings.Index(varStr, "=")
+		if eq == -1 {
+			// Weird...
+			continue
+		}
+		envName := varStr[:eq]
+		if envName == "TF_VAR_STRICT" {
+			// The strict-mode switch itself, not a variable value.
+			continue
+		}
+		name := envName[len(envVarPrefix):]
+		rawValue := varStr[eq+1:]
+
+		vc, exists := config[name]
+		if !exists {
+			if strict {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Value for undeclared variable",
+					fmt.Sprintf("The environment variable %s sets a value for a variable named %q, which the root module does not declare. To use this value, add a \"variable\" block to the configuration, or unset TF_VAR_STRICT to ignore values for undeclared variables.", envName, name),
+				))
+			}
+			// Otherwise, we tolerate and ignore attempts to set variables
+			// that are not declared in the configuration.
+			continue
+		}
+
+		rng := envVarSourceRange(envName)
+
+		expr, exprDiags := hclsyntax.ParseExpression([]byte(rawValue), envName, rng.Start)
+		diags = diags.Append(exprDiags)
+		if exprDiags.HasErrors() {
+			continue
+		}
+
+		val, valDiags := expr.Value(nil)
+		diags = diags.Append(valDiags)
+		if valDiags.HasErrors() {
+			continue
+		}
+
+		val, err := convert.Convert(val, vc.Type)
+		if err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid value for variable",
+				Detail:   fmt.Sprintf("The value of %s is not compatible with the variable's type constraint: %s.", envName, err),
+				Subject:  &rng,
+			})
+			continue
+		}
+
+		into[name] = &terraform.InputValue{
+			Value:       val,
+			SourceType:  terraform.ValueFromEnvVar,
+			SourceRange: rng,
+		}
+	}
+
+	return diags
+}
+
+// envVarSourceRange returns a synthetic hcl.Range for a TF_VAR_ environment
+// variable, so that diagnostics about its value can cite something more
+// useful than an unknown location: the environment variable's own name.
+func envVarSourceRange(envName string) hcl.Range {
+	return hcl.Range{
+		Filename: envName,
+		Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+		End:      hcl.Pos{Line: 1, Column: 1 + len(envName), Byte: len(envName)},
+	}
+}
+
 func (m *Meta) loadVariableValuesFromFile(filename string, config map[string]*configs.Variable, into terraform.InputValues) tfdiags.Diagnostics {
 	var diags tfdiags.Diagnostics
 
@@ -3,6 +3,7 @@ package configupgrade
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	hcl2 "github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/terraform/tfdiags"
@@ -50,8 +51,46 @@ func upgradeExpr(val interface{}, filename string, interp bool) ([]byte, tfdiags
 			diags = diags.Append(interpDiags)
 
 		case hcl1token.HEREDOC:
-			// TODO: Implement
-			panic("HEREDOC not supported yet")
+			marker, indented, body := parseHeredoc(tv.Token.Text)
+			if marker == "" {
+				diags = diags.Append(&hcl2.Diagnostic{
+					Severity: hcl2.DiagError,
+					Summary:  "Invalid heredoc template",
+					Detail:   "Failed to parse heredoc marker.",
+					Subject:  hcl1PosRange(filename, tv.Pos()).Ptr(),
+				})
+				break
+			}
+
+			if !interp {
+				writeHeredoc(&buf, marker, indented, escapeTemplateSequences(body))
+				break
+			}
+
+			var lines bytes.Buffer
+			for i, line := range strings.Split(body, "\n") {
+				if i > 0 {
+					lines.WriteByte('\n')
+				}
+
+				hilNode, err := hil.Parse(line)
+				if err != nil {
+					diags = diags.Append(&hcl2.Diagnostic{
+						Severity: hcl2.DiagError,
+						Summary:  "Invalid interpolated string",
+						Detail:   fmt.Sprintf("Interpolation parsing failed: %s", err),
+						Subject:  hcl1PosRange(filename, tv.Pos()).Ptr(),
+					})
+					lines.WriteString(escapeTemplateSequences(line))
+					continue
+				}
+
+				lineSrc, lineDiags := upgradeExpr(hilNode, filename, interp)
+				lines.Write(lineSrc)
+				diags = diags.Append(lineDiags)
+			}
+
+			writeHeredoc(&buf, marker, indented, lines.String())
 
 		case hcl1token.BOOL:
 			if litVal.(bool) {
@@ -87,3 +126,71 @@ func upgradeExpr(val interface{}, filename string, interp bool) ([]byte, tfdiags
 
 	return buf.Bytes(), diags
 }
+
+// parseHeredoc decodes the raw text of a HCL1 heredoc token, returning the
+// marker that delimits it, whether it used the "<<-" indented form, and the
+// body text with the marker lines removed.
+//
+// If the token text doesn't look like a valid heredoc at all then marker
+// will be returned as an empty string, which the caller should treat as an
+// error.
+func parseHeredoc(raw string) (marker string, indented bool, body string) {
+	lines := strings.SplitN(raw, "\n", 2)
+	if len(lines) != 2 {
+		return "", false, ""
+	}
+
+	header := strings.TrimRight(lines[0], "\r")
+	if !strings.HasPrefix(header, "<<") {
+		return "", false, ""
+	}
+	header = header[2:]
+	if strings.HasPrefix(header, "-") {
+		indented = true
+		header = header[1:]
+	}
+	marker = strings.TrimSpace(header)
+	if marker == "" {
+		return "", false, ""
+	}
+
+	rest := strings.TrimRight(lines[1], "\r\n")
+	// The lexer includes the trailing marker line (possibly indented) as
+	// part of the token text, so we must trim it off to get just the body.
+	if idx := strings.LastIndex(rest, "\n"); idx != -1 && strings.TrimSpace(rest[idx+1:]) == marker {
+		rest = rest[:idx]
+	} else if strings.TrimSpace(rest) == marker {
+		// The whole remainder is just the marker, i.e. an empty heredoc.
+		rest = ""
+	}
+
+	return marker, indented, rest
+}
+
+// writeHeredoc re-emits a heredoc in HCL2 syntax, given a marker, whether it
+// should use the "<<-" indented form, and a body that has already had any
+// interpolation sequences upgraded to HCL2 template syntax.
+func writeHeredoc(buf *bytes.Buffer, marker string, indented bool, body string) {
+	if indented {
+		buf.WriteString("<<-")
+	} else {
+		buf.WriteString("<<")
+	}
+	buf.WriteString(marker)
+	buf.WriteByte('\n')
+	buf.WriteString(body)
+	if !strings.HasSuffix(body, "\n") {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(marker)
+}
+
+// escapeTemplateSequences escapes any "${" or "%{" sequences in s that were
+// not produced by our own interpolation upgrade, so that literal heredoc
+// text containing these substrings round-trips correctly into HCL2 template
+// syntax instead of being misinterpreted as a template sequence.
+func escapeTemplateSequences(s string) string {
+	s = strings.Replace(s, "${", "$${", -1)
+	s = strings.Replace(s, "%{", "%%{", -1)
+	return s
+}
@@ -0,0 +1,37 @@
+package configs
+
+import (
+	"github.com/hashicorp/hcl2/hcl"
+
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// Provider represents a "provider" block in a module or file. A provider
+// block without a "for_each" expression configures a single provider
+// instance (the default, or a single alias); one with "for_each" expands
+// into one instance per element of the set it evaluates to, so that a
+// resource can pin itself to a specific instance with
+// provider = aws[each.key].
+type Provider struct {
+	Name  string
+	Alias string
+
+	// ForEach, if set, is the expression from the provider block's
+	// "for_each" argument. ProviderAliasExpansionTransformer evaluates it to
+	// decide which alias instances to create in place of this block's
+	// single placeholder node.
+	ForEach hcl.Expression
+
+	Config hcl.Body
+
+	DeclRange hcl.Range
+}
+
+// Addr returns the address of the provider configuration this block
+// declares, relative to its containing module.
+func (p *Provider) Addr() addrs.ProviderConfig {
+	return addrs.ProviderConfig{
+		Type:  p.Name,
+		Alias: p.Alias,
+	}
+}
@@ -0,0 +1,115 @@
+package configs
+
+import (
+	"github.com/hashicorp/hcl2/hcl"
+
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// Resource represents a "resource" or "data" block in a module or file.
+type Resource struct {
+	Mode   addrs.ResourceMode
+	Name   string
+	Type   string
+	Config hcl.Body
+
+	Count   hcl.Expression
+	ForEach hcl.Expression
+
+	ProviderConfigRef *ProviderConfigRef
+
+	// ProviderInstanceKey records the "provider_instance" meta-argument, if
+	// set, pinning this resource to a specific pooled instance of its
+	// provider configuration rather than letting it share the module's
+	// single instance.
+	ProviderInstanceKey string
+
+	DependsOn []hcl.Traversal
+
+	// Provisioners lists every provisioner block on the resource, regardless
+	// of its "when". ProvisionedBy uses this to report which provisioner
+	// types a resource needs, independent of when each one runs.
+	Provisioners []*Provisioner
+
+	Managed *ManagedResource
+
+	DeclRange hcl.Range
+	TypeRange hcl.Range
+}
+
+// ManagedResource represents the additional fields that are only relevant
+// to managed ("resource") blocks, as opposed to data resources.
+type ManagedResource struct {
+	Connection   *hcl.Block
+	Provisioners []*Provisioner
+
+	CreateBeforeDestroy    bool
+	CreateBeforeDestroySet bool
+
+	PreventDestroy    bool
+	PreventDestroySet bool
+
+	IgnoreAllChanges bool
+}
+
+// ProviderConfigAddr returns the address of the provider configuration this
+// resource is associated with. If the resource has an explicit "provider"
+// meta-argument, that's reflected in the result; otherwise the default
+// provider configuration implied by the resource type is returned.
+func (r *Resource) ProviderConfigAddr() addrs.ProviderConfig {
+	if r.ProviderConfigRef == nil {
+		return addrs.NewDefaultProviderConfig(r.Type)
+	}
+
+	return addrs.ProviderConfig{
+		Type:  r.ProviderConfigRef.Name,
+		Alias: r.ProviderConfigRef.Alias,
+	}
+}
+
+// ProviderConfigRef is the result of decoding a resource's "provider"
+// meta-argument, e.g. provider = aws.west or provider = aws[each.key].
+type ProviderConfigRef struct {
+	Name      string
+	NameRange hcl.Range
+	Alias     string
+
+	// KeyExpression is set instead of Alias when the meta-argument indexes
+	// into a provider block that expanded via "for_each", e.g. the
+	// "[each.key]" in provider = aws[each.key]. Which alias it refers to
+	// depends on the resource's own each.key/each.value, so it can only be
+	// resolved once those are available; see
+	// NodeAbstractResource.ProvidedBy.
+	KeyExpression hcl.Expression
+}
+
+// Provisioner represents a "provisioner" block within a resource.
+type Provisioner struct {
+	Type       string
+	Config     hcl.Body
+	Connection *hcl.Block
+	When       ProvisionerWhen
+	OnFailure  ProvisionerOnFailure
+
+	DeclRange hcl.Range
+	TypeRange hcl.Range
+}
+
+// ProvisionerWhen is an enum for valid values for when to run a provisioner.
+type ProvisionerWhen int
+
+const (
+	ProvisionerWhenInvalid ProvisionerWhen = iota
+	ProvisionerWhenCreate
+	ProvisionerWhenDestroy
+)
+
+// ProvisionerOnFailure is an enum for valid values for on_failure options
+// for provisioners.
+type ProvisionerOnFailure int
+
+const (
+	ProvisionerOnFailureInvalid ProvisionerOnFailure = iota
+	ProvisionerOnFailureContinue
+	ProvisionerOnFailureFail
+)
@@ -0,0 +1,61 @@
+package plugin
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// Client launches a single plugin binary and negotiates the protocol
+// version to use with it, the client-side counterpart to Serve.
+type Client struct {
+	*plugin.Client
+
+	// ProtocolVersion is the plugin protocol version this client and the
+	// launched plugin negotiated, selected by NegotiateProtocolVersion
+	// before the plugin was launched.
+	ProtocolVersion int
+}
+
+// NewClient launches the plugin binary described by cmd and negotiates the
+// protocol version to use with it via NegotiateProtocolVersion.
+// pluginVersions is the set of protocol versions the plugin advertises
+// (typically discovered ahead of time by probing the binary, or assumed to
+// be just Handshake.ProtocolVersion for a plugin too old to advertise
+// any). current and versioned mirror ServeOpts' own plugin set and
+// VersionedPlugins: current is dispensed if the negotiated version is
+// core's own Handshake.ProtocolVersion, and versioned[version] is
+// dispensed otherwise.
+//
+// Without this, a protocol mismatch surfaced only once go-plugin's own
+// handshake failed deep inside Client(), as an opaque dispense error; by
+// checking compatibility up front, we can report it the same descriptive
+// way NegotiateProtocolVersion always has, before a process is even
+// launched.
+func NewClient(cmd *exec.Cmd, pluginVersions []int, current plugin.PluginSet, versioned map[int]plugin.PluginSet) (*Client, error) {
+	coreVersions := make([]int, 0, len(versioned)+1)
+	coreVersions = append(coreVersions, Handshake.ProtocolVersion)
+	for v := range versioned {
+		coreVersions = append(coreVersions, v)
+	}
+
+	version, err := NegotiateProtocolVersion(coreVersions, pluginVersions)
+	if err != nil {
+		return nil, fmt.Errorf("can't launch plugin %s: %s", cmd.Path, err)
+	}
+
+	pluginSet := current
+	if version != Handshake.ProtocolVersion {
+		pluginSet = versioned[version]
+	}
+
+	c := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  Handshake,
+		Plugins:          pluginSet,
+		Cmd:              cmd,
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+	})
+
+	return &Client{Client: c, ProtocolVersion: version}, nil
+}
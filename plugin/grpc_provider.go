@@ -2,12 +2,23 @@ package plugin
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
 
+	"github.com/hashicorp/terraform/config/configschema"
+	"github.com/hashicorp/terraform/config/hcl2shim"
 	"github.com/hashicorp/terraform/plugin/proto"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/vmihailenco/msgpack"
+	"github.com/zclconf/go-cty/cty"
+	ctymsgpack "github.com/zclconf/go-cty/cty/msgpack"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // temporary functions for dealing with the old warning and errors slices
@@ -48,20 +59,121 @@ func warnsAndErrs(diags []*proto.Diagnostic) ([]string, []error) {
 	return warns, errs
 }
 
-// Temporary functions to pack and unpack terraform types into a DynamicValue
-func dynamicValue(i interface{}) *proto.DynamicValue {
-	js, err := json.Marshal(i)
+// dynamicValue and unDynamicValue pack and unpack payloads that aren't sent
+// as schema-typed cty values: the provider's own schema response,
+// terraform.InstanceDiff values, and the info+state/info+config request
+// bundles a few RPCs below use internally. These go through a
+// reflection-based MessagePack encoding of our Go structs.
+//
+// A resource's config and state instead go through
+// dynamicValueFromConfig/configFromDynamicValue and
+// dynamicValueFromState/stateFromDynamicValue below, which marshal a real
+// cty.Value with cty/msgpack against schema.ImpliedType() -- the wire
+// format an actual provider SDK expects, rather than an encoding of our
+// internal Go struct layout.
+func dynamicValue(i interface{}) (*proto.DynamicValue, error) {
+	raw, err := msgpack.Marshal(i)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to encode %T as msgpack: %s", i, err)
 	}
 
-	return &proto.DynamicValue{Msgpack: js}
+	return &proto.DynamicValue{Msgpack: raw}, nil
 }
 
-func unDynamicValue(v *proto.DynamicValue, i interface{}) {
-	if err := json.Unmarshal(v.Msgpack, i); err != nil {
-		panic(err)
+func unDynamicValue(v *proto.DynamicValue, i interface{}) error {
+	if v == nil || len(v.Msgpack) == 0 {
+		return nil
+	}
+	if err := msgpack.Unmarshal(v.Msgpack, i); err != nil {
+		return fmt.Errorf("failed to decode msgpack into %T: %s", i, err)
+	}
+	return nil
+}
+
+// dynamicValueFromCtyValue and ctyValueFromDynamicValue are the cty/msgpack
+// encode/decode steps shared by the config- and state-specific helpers
+// below; both marshal against schema.ImpliedType(), the same type a real
+// provider would check the value against.
+func dynamicValueFromCtyValue(val cty.Value, schema *configschema.Block) (*proto.DynamicValue, error) {
+	raw, err := ctymsgpack.Marshal(val, schema.ImpliedType())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value as msgpack: %s", err)
 	}
+	return &proto.DynamicValue{Msgpack: raw}, nil
+}
+
+func ctyValueFromDynamicValue(v *proto.DynamicValue, schema *configschema.Block) (cty.Value, error) {
+	ty := schema.ImpliedType()
+	if v == nil || len(v.Msgpack) == 0 {
+		return cty.NullVal(ty), nil
+	}
+	val, err := ctymsgpack.Unmarshal(v.Msgpack, ty)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("failed to decode msgpack into %s: %s", ty.FriendlyName(), err)
+	}
+	return val, nil
+}
+
+// dynamicValueFromConfig converts a legacy *terraform.ResourceConfig into a
+// DynamicValue by first coercing it into a cty.Value conforming to schema,
+// the same conversion helper/plugin's GRPCProviderServer.Configure etc. use
+// to bridge the old HCL1-shaped config map and the provider protocol's
+// cty.Value wire format.
+func dynamicValueFromConfig(c *terraform.ResourceConfig, schema *configschema.Block) (*proto.DynamicValue, error) {
+	var raw map[string]interface{}
+	if c != nil {
+		raw = c.Config
+	}
+
+	val := hcl2shim.HCL2ValueFromConfigValue(raw)
+	val, err := schema.CoerceValue(val)
+	if err != nil {
+		return nil, fmt.Errorf("failed to coerce config to schema type: %s", err)
+	}
+
+	return dynamicValueFromCtyValue(val, schema)
+}
+
+// configFromDynamicValue is dynamicValueFromConfig's inverse, used on the
+// server side to decode an incoming config back into the legacy
+// *terraform.ResourceConfig shape that terraform.ResourceProvider methods
+// still expect.
+func configFromDynamicValue(v *proto.DynamicValue, schema *configschema.Block) (*terraform.ResourceConfig, error) {
+	val, err := ctyValueFromDynamicValue(v, schema)
+	if err != nil {
+		return nil, err
+	}
+	return terraform.NewResourceConfigShimmed(val, schema), nil
+}
+
+// dynamicValueFromState converts a legacy *terraform.InstanceState into a
+// DynamicValue via its own AttrsAsObjectValue helper, which already knows
+// how to expand its flatmapped Attributes into a cty.Value of the given
+// type.
+func dynamicValueFromState(s *terraform.InstanceState, schema *configschema.Block) (*proto.DynamicValue, error) {
+	ty := schema.ImpliedType()
+	if s == nil || s.Attributes == nil {
+		return dynamicValueFromCtyValue(cty.NullVal(ty), schema)
+	}
+
+	val, err := s.AttrsAsObjectValue(ty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert state to schema type: %s", err)
+	}
+
+	return dynamicValueFromCtyValue(val, schema)
+}
+
+// stateFromDynamicValue is dynamicValueFromState's inverse. schemaVersion is
+// recorded on the resulting InstanceState as-is; tracking the version a
+// given state was actually written against happens one layer up, outside
+// this RPC boundary, so callers that don't have it to hand pass 0.
+func stateFromDynamicValue(v *proto.DynamicValue, schemaVersion int, schema *configschema.Block) (*terraform.InstanceState, error) {
+	val, err := ctyValueFromDynamicValue(v, schema)
+	if err != nil {
+		return nil, err
+	}
+	return terraform.NewInstanceStateShimmedFromValue(val, schemaVersion), nil
 }
 
 // terraform.ResourceProvider grpc implementation
@@ -72,6 +184,98 @@ type GRPCResourceProvider struct {
 	// this context is created by the plugin package, and is canceled when the
 	// plugin process ends.
 	ctx context.Context
+
+	// capsMu guards caps, which is filled in by GetSchema's first round
+	// trip to the provider and cached for the lifetime of the connection:
+	// a provider's capabilities can't change without restarting the
+	// plugin process, so there's no reason to ask more than once.
+	capsMu sync.Mutex
+	caps   *proto.ServerCapabilities
+
+	// schemaMu guards schema, the provider's full schema, fetched and
+	// cached on first use by providerSchema: like caps, a provider's
+	// schema can't change without restarting the plugin process.
+	schemaMu sync.Mutex
+	schema   *terraform.ProviderSchema
+}
+
+// providerSchema returns the provider's full schema, fetching it via
+// GetSchema on first use and reusing the cached copy afterward.
+func (p *GRPCResourceProvider) providerSchema() (*terraform.ProviderSchema, error) {
+	p.schemaMu.Lock()
+	cached := p.schema
+	p.schemaMu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	return p.GetSchema(&terraform.ProviderSchemaRequest{All: true})
+}
+
+// providerBlock returns the configschema.Block governing the provider's own
+// configuration, as opposed to one of its resources or data sources.
+func (p *GRPCResourceProvider) providerBlock() (*configschema.Block, error) {
+	s, err := p.providerSchema()
+	if err != nil {
+		return nil, err
+	}
+	return s.Provider, nil
+}
+
+// schemaFor returns the configschema.Block governing resourceType's
+// configuration and state (or, if dataSource is set, a data source's
+// config and result).
+func (p *GRPCResourceProvider) schemaFor(resourceType string, dataSource bool) (*configschema.Block, error) {
+	s, err := p.providerSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, kind := s.ResourceTypes, "resource type"
+	if dataSource {
+		blocks, kind = s.DataSources, "data source"
+	}
+
+	block, ok := blocks[resourceType]
+	if !ok {
+		return nil, fmt.Errorf("no schema available for %s %q", kind, resourceType)
+	}
+	return block, nil
+}
+
+// Capabilities reports which optional RPCs the provider advertises support
+// for, fetching and caching them via GetSchema if that hasn't happened yet.
+// Core code should consult this before calling an optional RPC like
+// UpgradeResourceState rather than discovering the lack of support only
+// after hitting its stub implementation.
+func (p *GRPCResourceProvider) Capabilities() (*proto.ServerCapabilities, error) {
+	p.capsMu.Lock()
+	cached := p.caps
+	p.capsMu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	if _, err := p.GetSchema(&terraform.ProviderSchemaRequest{All: true}); err != nil {
+		return nil, err
+	}
+
+	p.capsMu.Lock()
+	defer p.capsMu.Unlock()
+	return p.caps, nil
+}
+
+// ProviderVersion implements terraform.ProviderVersioner, reporting the
+// plugin protocol version the provider was built against, fetched and
+// cached the same way as Capabilities. It's the only version information a
+// provider plugin reports over this wire protocol; there's no separate
+// provider-release version (e.g. "2.3.1") available to report here.
+func (p *GRPCResourceProvider) ProviderVersion() (string, bool) {
+	caps, err := p.Capabilities()
+	if err != nil || caps == nil || caps.ProtocolVersion == 0 {
+		return "", false
+	}
+	return strconv.FormatInt(caps.ProtocolVersion, 10), true
 }
 
 func (p *GRPCResourceProvider) Stop() error {
@@ -86,65 +290,142 @@ func (p *GRPCResourceProvider) Stop() error {
 	return nil
 }
 
+// GetSchema retrieves the provider's schema. Regardless of the contents of
+// req, this always performs a single round-trip that returns the full
+// provider/resource/datasource/provisioner schema; req.All exists so that
+// callers can document their intent, and legacy callers that still populate
+// req.ResourceTypes/req.DataSources continue to work unchanged since those
+// fields are simply ignored server-side.
 func (p *GRPCResourceProvider) GetSchema(req *terraform.ProviderSchemaRequest) (*terraform.ProviderSchema, error) {
 	resp, err := p.client.GetSchema(p.ctx, &proto.GetSchema_Request{})
 	if err != nil {
 		return nil, err
 	}
 
+	p.capsMu.Lock()
+	p.caps = resp.ServerCapabilities
+	p.capsMu.Unlock()
+
 	s := &terraform.ProviderSchema{}
-	unDynamicValue(resp.ProviderSchema, s)
+	if err := unDynamicValue(resp.ProviderSchema, s); err != nil {
+		return nil, err
+	}
+
+	p.schemaMu.Lock()
+	p.schema = s
+	p.schemaMu.Unlock()
+
 	return s, nil
 }
 
+// GetAllSchemas is equivalent to GetSchema(&terraform.ProviderSchemaRequest{All: true})
+// and exists as a convenience for callers that always want the bulk form.
+func (p *GRPCResourceProvider) GetAllSchemas() (*terraform.ProviderSchema, error) {
+	return p.GetSchema(&terraform.ProviderSchemaRequest{All: true})
+}
+
 func (p *GRPCResourceProvider) Input(input terraform.UIInput, c *terraform.ResourceConfig) (*terraform.ResourceConfig, error) {
 	return nil, errors.New("Not Implemented")
 }
 
-func (p *GRPCResourceProvider) Validate(c *terraform.ResourceConfig) ([]string, []error) {
+func (p *GRPCResourceProvider) Validate(c *terraform.ResourceConfig) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	schema, err := p.providerBlock()
+	if err != nil {
+		diags = diags.Append(err)
+		return diags
+	}
+
+	cfg, err := dynamicValueFromConfig(c, schema)
+	if err != nil {
+		diags = diags.Append(err)
+		return diags
+	}
+
 	req := &proto.ValidateProviderConfig_Request{
-		Config: dynamicValue(c),
+		Config: cfg,
 	}
 	resp, err := p.client.ValidateProviderConfig(p.ctx, req)
 	if err != nil {
-		return nil, []error{err}
+		diags = diags.Append(err)
+		return diags
 	}
 
-	return warnsAndErrs(resp.Diagnostics)
+	return diags.Append(proto.TFDiagnostics(resp.Diagnostics))
 }
 
-func (p *GRPCResourceProvider) ValidateResource(t string, c *terraform.ResourceConfig) ([]string, []error) {
+func (p *GRPCResourceProvider) ValidateResource(t string, c *terraform.ResourceConfig) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	schema, err := p.schemaFor(t, false)
+	if err != nil {
+		diags = diags.Append(err)
+		return diags
+	}
+
+	cfg, err := dynamicValueFromConfig(c, schema)
+	if err != nil {
+		diags = diags.Append(err)
+		return diags
+	}
+
 	req := &proto.ValidateResourceTypeConfig_Request{
 		ResourceTypeName: t,
-		Config:           dynamicValue(c),
+		Config:           cfg,
 	}
 
 	resp, err := p.client.ValidateResourceTypeConfig(p.ctx, req)
 	if err != nil {
-		return nil, []error{err}
+		diags = diags.Append(err)
+		return diags
 	}
 
-	return warnsAndErrs(resp.Diagnostics)
+	return diags.Append(proto.TFDiagnostics(resp.Diagnostics))
 }
 
-func (p *GRPCResourceProvider) ValidateDataSource(t string, c *terraform.ResourceConfig) ([]string, []error) {
+func (p *GRPCResourceProvider) ValidateDataSource(t string, c *terraform.ResourceConfig) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	schema, err := p.schemaFor(t, true)
+	if err != nil {
+		diags = diags.Append(err)
+		return diags
+	}
+
+	cfg, err := dynamicValueFromConfig(c, schema)
+	if err != nil {
+		diags = diags.Append(err)
+		return diags
+	}
+
 	req := &proto.ValidateDataSourceConfig_Request{
 		DataSourceName: t,
-		Config:         dynamicValue(c),
+		Config:         cfg,
 	}
 
 	resp, err := p.client.ValidateDataSourceConfig(p.ctx, req)
 	if err != nil {
-		return nil, []error{err}
+		diags = diags.Append(err)
+		return diags
 	}
 
-	return warnsAndErrs(resp.Diagnostics)
-
+	return diags.Append(proto.TFDiagnostics(resp.Diagnostics))
 }
 
 func (p *GRPCResourceProvider) Configure(c *terraform.ResourceConfig) error {
+	schema, err := p.providerBlock()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := dynamicValueFromConfig(c, schema)
+	if err != nil {
+		return err
+	}
+
 	req := &proto.Configure_Request{
-		Config: dynamicValue(c),
+		Config: cfg,
 	}
 
 	resp, err := p.client.Configure(p.ctx, req)
@@ -160,17 +441,19 @@ func (p *GRPCResourceProvider) Configure(c *terraform.ResourceConfig) error {
 }
 
 func (p *GRPCResourceProvider) Refresh(info *terraform.InstanceInfo, s *terraform.InstanceState) (*terraform.InstanceState, error) {
-	args := struct {
-		Info  *terraform.InstanceInfo
-		State *terraform.InstanceState
-	}{
-		Info:  info,
-		State: s,
+	schema, err := p.schemaFor(info.Type, false)
+	if err != nil {
+		return nil, err
+	}
+
+	currentState, err := dynamicValueFromState(s, schema)
+	if err != nil {
+		return nil, err
 	}
 
 	req := &proto.ReadResource_Request{
 		ResourceTypeName: info.Type,
-		CurrentState:     dynamicValue(args),
+		CurrentState:     currentState,
 	}
 
 	resp, err := p.client.ReadResource(p.ctx, req)
@@ -178,16 +461,28 @@ func (p *GRPCResourceProvider) Refresh(info *terraform.InstanceInfo, s *terrafor
 		return nil, err
 	}
 
-	newState := &terraform.InstanceState{}
-	unDynamicValue(resp.NewState, newState)
-	return newState, nil
+	return stateFromDynamicValue(resp.NewState, 0, schema)
 }
 
 func (p *GRPCResourceProvider) Diff(info *terraform.InstanceInfo, s *terraform.InstanceState, c *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+	schema, err := p.schemaFor(info.Type, false)
+	if err != nil {
+		return nil, err
+	}
+
+	priorState, err := dynamicValueFromState(s, schema)
+	if err != nil {
+		return nil, err
+	}
+	proposedNewState, err := dynamicValueFromConfig(c, schema)
+	if err != nil {
+		return nil, err
+	}
+
 	req := &proto.PlanResourceChange_Request{
 		ResourceTypeName: info.Type,
-		PriorState:       dynamicValue(s),
-		ProposedNewState: dynamicValue(c),
+		PriorState:       priorState,
+		ProposedNewState: proposedNewState,
 	}
 
 	resp, err := p.client.PlanResourceChange(p.ctx, req)
@@ -195,35 +490,125 @@ func (p *GRPCResourceProvider) Diff(info *terraform.InstanceInfo, s *terraform.I
 		return nil, err
 	}
 
+	// PlannedNewState is decoded as a legacy InstanceDiff rather than through
+	// schema/cty: a diff has no cty.Value shape of its own (it's a
+	// per-attribute old/new/computed structure), and real Terraform's own
+	// plan RPC already returns the planned *new state*, not a diff, so
+	// reshaping what PlanResourceChange actually computes is a separate
+	// change from fixing this RPC layer's wire format.
 	diff := &terraform.InstanceDiff{}
-	unDynamicValue(resp.PlannedNewState, diff)
+	if err := unDynamicValue(resp.PlannedNewState, diff); err != nil {
+		return nil, err
+	}
 
 	diags := proto.TFDiagnostics(resp.Diagnostics)
 
 	return diff, diags.Err()
 }
 
+// Apply streams the ApplyResourceChange RPC so that diagnostics emitted
+// partway through a long-running apply reach us as soon as the provider
+// sends them, rather than only once the whole operation has finished.
+//
+// Note that terraform.ResourceProvider.Apply has no progress-reporting
+// parameter of its own (unlike terraform.ResourceProvisioner.Apply, which
+// takes a UIOutput), so intermediate diagnostics are accumulated here and
+// surfaced together with the final ones rather than forwarded live; wiring
+// them further up to the UI as they arrive needs the eval context to grow
+// a diagnostics sink of its own.
 func (p *GRPCResourceProvider) Apply(info *terraform.InstanceInfo, s *terraform.InstanceState, d *terraform.InstanceDiff) (*terraform.InstanceState, error) {
+	schema, err := p.schemaFor(info.Type, false)
+	if err != nil {
+		return nil, err
+	}
+
+	priorState, err := dynamicValueFromState(s, schema)
+	if err != nil {
+		return nil, err
+	}
+	// d, like the InstanceDiff decoded in Diff above, has no cty.Value
+	// shape of its own, so it still travels as legacy MessagePack.
+	plannedNewState, err := dynamicValue(d)
+	if err != nil {
+		return nil, err
+	}
+
 	req := &proto.ApplyResourceChange_Request{
 		ResourceTypeName: info.Type,
-		PriorState:       dynamicValue(s),
-		PlannedNewState:  dynamicValue(d),
+		PriorState:       priorState,
+		PlannedNewState:  plannedNewState,
 	}
 
-	resp, err := p.client.ApplyResourceChange(p.ctx, req)
+	if caps, err := p.Capabilities(); err == nil && caps != nil && !caps.StreamingApply {
+		return p.applyResourceChangeUnary(req, schema)
+	}
+
+	stream, err := p.client.ApplyResourceChange(p.ctx, req)
 	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			// The provider advertised no capabilities (e.g. an older
+			// protocol version that predates ServerCapabilities entirely)
+			// but still doesn't understand the streaming call, so fall
+			// back the same way we would if it had told us in advance.
+			return p.applyResourceChangeUnary(req, schema)
+		}
 		return nil, err
 	}
 
-	state := &terraform.InstanceState{}
-	unDynamicValue(resp.NewState, state)
+	var diags tfdiags.Diagnostics
+	state := terraform.NewInstanceStateShimmedFromValue(cty.NullVal(schema.ImpliedType()), 0)
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if p.ctx.Err() != nil {
+				// p.ctx was canceled out from under us, which is the same
+				// signal Stop uses to ask the provider to abandon what
+				// it's doing, so report it the same way.
+				return state, p.Stop()
+			}
+			return nil, err
+		}
 
-	diags := proto.TFDiagnostics(resp.Diagnostics)
+		diags = diags.Append(proto.TFDiagnostics(resp.Diagnostics))
+		if resp.NewState != nil {
+			newState, err := stateFromDynamicValue(resp.NewState, 0, schema)
+			if err != nil {
+				return nil, err
+			}
+			state = newState
+		}
+	}
+
+	return state, diags.Err()
+}
 
+// applyResourceChangeUnary falls back to a single request/response
+// ApplyResourceChange call for providers that don't implement the
+// streaming form above.
+func (p *GRPCResourceProvider) applyResourceChangeUnary(req *proto.ApplyResourceChange_Request, schema *configschema.Block) (*terraform.InstanceState, error) {
+	resp, err := p.client.ApplyResourceChangeUnary(p.ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := stateFromDynamicValue(resp.NewState, 0, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	diags := proto.TFDiagnostics(resp.Diagnostics)
 	return state, diags.Err()
 }
 
 func (p *GRPCResourceProvider) ImportState(info *terraform.InstanceInfo, id string) ([]*terraform.InstanceState, error) {
+	schema, err := p.schemaFor(info.Type, false)
+	if err != nil {
+		return nil, err
+	}
+
 	req := &proto.ImportResourceState_Request{
 		ResourceTypeName: info.Type,
 		Id:               id,
@@ -234,11 +619,62 @@ func (p *GRPCResourceProvider) ImportState(info *terraform.InstanceInfo, id stri
 		return nil, err
 	}
 
-	newState := []*terraform.InstanceState{}
-	unDynamicValue(resp.NewState, &newState)
+	// NewState carries every imported object under a single
+	// ResourceTypeName, so all of them are decoded against that one
+	// resource type's schema; an import that fans out into more than one
+	// resource type (e.g. a composite ID that yields a parent and a child)
+	// isn't representable by this simplified request/response shape.
+	newState := []*proto.DynamicValue{}
+	if err := unDynamicValue(resp.NewState, &newState); err != nil {
+		return nil, err
+	}
+
+	states := make([]*terraform.InstanceState, len(newState))
+	for i, v := range newState {
+		s, err := stateFromDynamicValue(v, 0, schema)
+		if err != nil {
+			return nil, err
+		}
+		states[i] = s
+	}
 
 	diags := proto.TFDiagnostics(resp.Diagnostics)
-	return newState, diags.Err()
+	return states, diags.Err()
+}
+
+// UpgradeState asks the provider to migrate state that was written against
+// an older schema version into a value compatible with its current schema.
+// rawJSON is the legacy flatmap-as-JSON state exactly as it was persisted;
+// the provider decodes it itself since only it knows how its own schema has
+// evolved across versions.
+func (p *GRPCResourceProvider) UpgradeState(info *terraform.InstanceInfo, rawJSON []byte, schemaVersion int) (*terraform.InstanceState, error) {
+	if caps, err := p.Capabilities(); err == nil && caps != nil && !caps.UpgradeState {
+		return nil, fmt.Errorf("provider for resource type %q does not support upgrading state written by an older schema version", info.Type)
+	}
+
+	schema, err := p.schemaFor(info.Type, false)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &proto.UpgradeResourceState_Request{
+		ResourceTypeName: info.Type,
+		Version:          int64(schemaVersion),
+		RawState:         &proto.DynamicValue{Json: rawJSON},
+	}
+
+	resp, err := p.client.UpgradeResourceState(p.ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := stateFromDynamicValue(resp.UpgradedState, schemaVersion, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	diags := proto.TFDiagnostics(resp.Diagnostics)
+	return state, diags.Err()
 }
 
 func (p *GRPCResourceProvider) Resources() []terraform.ResourceType {
@@ -257,8 +693,13 @@ func (p *GRPCResourceProvider) Resources() []terraform.ResourceType {
 }
 
 func (p *GRPCResourceProvider) ReadDataDiff(info *terraform.InstanceInfo, c *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+	request, err := dynamicValue([]interface{}{info, c})
+	if err != nil {
+		return nil, err
+	}
+
 	req := &proto.ReadDataSource_Request{
-		Request: dynamicValue([]interface{}{info, c}),
+		Request: request,
 	}
 
 	resp, err := p.client.TempDiffDataSource(p.ctx, req)
@@ -267,15 +708,23 @@ func (p *GRPCResourceProvider) ReadDataDiff(info *terraform.InstanceInfo, c *ter
 	}
 
 	diff := &terraform.InstanceDiff{}
-	unDynamicValue(resp.Result, diff)
+	if err := unDynamicValue(resp.Result, diff); err != nil {
+		return nil, err
+	}
 
 	diags := proto.TFDiagnostics(resp.Diagnostics)
 	return diff, diags.Err()
 }
 
 func (p *GRPCResourceProvider) ReadDataApply(info *terraform.InstanceInfo, d *terraform.InstanceDiff) (*terraform.InstanceState, error) {
+	request, err := dynamicValue(d)
+	if err != nil {
+		return nil, err
+	}
+
 	req := &proto.ReadDataSource_Request{
-		Request: dynamicValue(d),
+		DataSourceName: info.Type,
+		Request:        request,
 	}
 
 	resp, err := p.client.ReadDataSource(p.ctx, req)
@@ -283,8 +732,15 @@ func (p *GRPCResourceProvider) ReadDataApply(info *terraform.InstanceInfo, d *te
 		return nil, err
 	}
 
-	state := &terraform.InstanceState{}
-	unDynamicValue(resp.Result, state)
+	schema, err := p.schemaFor(info.Type, true)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := stateFromDynamicValue(resp.Result, 0, schema)
+	if err != nil {
+		return nil, err
+	}
 
 	diags := proto.TFDiagnostics(resp.Diagnostics)
 	return state, diags.Err()
@@ -312,40 +768,142 @@ func (p *GRPCResourceProvider) Close() error {
 
 type GRPCResourceProviderServer struct {
 	provider terraform.ResourceProvider
+
+	// schemaMu guards schema, the wrapped provider's full schema, fetched
+	// and cached on first use the same way GRPCResourceProvider caches it
+	// client-side: the in-process provider's schema can't change over the
+	// server's lifetime either.
+	schemaMu sync.Mutex
+	schema   *terraform.ProviderSchema
+}
+
+// providerSchema returns the wrapped provider's full schema, calling its
+// GetSchema directly (no RPC needed, since the server already holds the
+// provider) and caching the result.
+func (s *GRPCResourceProviderServer) providerSchema() (*terraform.ProviderSchema, error) {
+	s.schemaMu.Lock()
+	cached := s.schema
+	s.schemaMu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	ps, err := s.provider.GetSchema(&terraform.ProviderSchemaRequest{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	s.schemaMu.Lock()
+	s.schema = ps
+	s.schemaMu.Unlock()
+	return ps, nil
+}
+
+// providerBlock returns the configschema.Block governing the wrapped
+// provider's own configuration.
+func (s *GRPCResourceProviderServer) providerBlock() (*configschema.Block, error) {
+	ps, err := s.providerSchema()
+	if err != nil {
+		return nil, err
+	}
+	return ps.Provider, nil
+}
+
+// schemaFor returns the configschema.Block governing resourceType's
+// configuration and state (or, if dataSource is set, a data source's
+// config and result).
+func (s *GRPCResourceProviderServer) schemaFor(resourceType string, dataSource bool) (*configschema.Block, error) {
+	ps, err := s.providerSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, kind := ps.ResourceTypes, "resource type"
+	if dataSource {
+		blocks, kind = ps.DataSources, "data source"
+	}
+
+	block, ok := blocks[resourceType]
+	if !ok {
+		return nil, fmt.Errorf("no schema available for %s %q", kind, resourceType)
+	}
+	return block, nil
 }
 
 func (s *GRPCResourceProviderServer) GetSchema(_ context.Context, req *proto.GetSchema_Request) (*proto.GetSchema_Response, error) {
-	// GetSchema must return the full schema
-	ps, err := s.provider.GetSchema(nil)
+	// GetSchema always returns the full provider/resource/datasource schema
+	// in one round-trip, so that core never needs to ask the provider to
+	// enumerate its own resource and data source names first just to build
+	// the request that asks for everything.
+	ps, err := s.provider.GetSchema(&terraform.ProviderSchemaRequest{All: true})
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.GetSchema_Response{ProviderSchema: dynamicValue(ps)}, nil
+	schemaVal, err := dynamicValue(ps)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.GetSchema_Response{
+		ProviderSchema:     schemaVal,
+		ServerCapabilities: serverCapabilities,
+	}, nil
+}
+
+// serverCapabilities describes the optional RPCs this build of
+// GRPCResourceProviderServer implements, so that a client talking to an
+// older or newer plugin binary can tell which of them are safe to call
+// instead of finding out the hard way by hitting a stub.
+var serverCapabilities = &proto.ServerCapabilities{
+	ProtocolVersion: 4,
+	StreamingApply:  true,
+	UpgradeState:    true,
+	PlanDestroy:     true,
 }
 
 func (s *GRPCResourceProviderServer) ValidateProviderConfig(_ context.Context, req *proto.ValidateProviderConfig_Request) (*proto.ValidateProviderConfig_Response, error) {
-	rc := &terraform.ResourceConfig{}
-	unDynamicValue(req.Config, rc)
+	schema, err := s.providerBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := configFromDynamicValue(req.Config, schema)
+	if err != nil {
+		return nil, err
+	}
 
-	warns, errs := s.provider.Validate(rc)
+	diags := s.provider.Validate(rc)
 
-	return &proto.ValidateProviderConfig_Response{Diagnostics: diagnostics(warns, errs)}, nil
+	return &proto.ValidateProviderConfig_Response{Diagnostics: proto.NewDiagnostics(diags)}, nil
 }
 
 func (s *GRPCResourceProviderServer) ValidateResourceTypeConfig(_ context.Context, req *proto.ValidateResourceTypeConfig_Request) (*proto.ValidateResourceTypeConfig_Response, error) {
-	cfg := &terraform.ResourceConfig{}
-	unDynamicValue(req.Config, cfg)
+	schema, err := s.schemaFor(req.ResourceTypeName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := configFromDynamicValue(req.Config, schema)
+	if err != nil {
+		return nil, err
+	}
 
-	w, e := s.provider.ValidateResource(req.ResourceTypeName, cfg)
-	return &proto.ValidateResourceTypeConfig_Response{Diagnostics: diagnostics(w, e)}, nil
+	diags := s.provider.ValidateResource(req.ResourceTypeName, cfg)
+	return &proto.ValidateResourceTypeConfig_Response{Diagnostics: proto.NewDiagnostics(diags)}, nil
 }
 
 func (s *GRPCResourceProviderServer) Configure(_ context.Context, req *proto.Configure_Request) (*proto.Configure_Response, error) {
-	cfg := &terraform.ResourceConfig{}
-	unDynamicValue(req.Config, cfg)
+	schema, err := s.providerBlock()
+	if err != nil {
+		return nil, err
+	}
 
-	err := s.provider.Configure(cfg)
+	cfg, err := configFromDynamicValue(req.Config, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.provider.Configure(cfg)
 	var errs []error
 	if err != nil {
 		errs = append(errs, err)
@@ -355,76 +913,174 @@ func (s *GRPCResourceProviderServer) Configure(_ context.Context, req *proto.Con
 }
 
 func (s *GRPCResourceProviderServer) ReadResource(_ context.Context, req *proto.ReadResource_Request) (*proto.ReadResource_Response, error) {
-	args := struct {
-		Info  *terraform.InstanceInfo
-		State *terraform.InstanceState
-	}{}
+	schema, err := s.schemaFor(req.ResourceTypeName, false)
+	if err != nil {
+		return nil, err
+	}
 
-	unDynamicValue(req.CurrentState, &args)
+	currentState, err := stateFromDynamicValue(req.CurrentState, 0, schema)
+	if err != nil {
+		return nil, err
+	}
 
-	is, err := s.provider.Refresh(args.Info, args.State)
+	info := &terraform.InstanceInfo{Type: req.ResourceTypeName}
+	is, err := s.provider.Refresh(info, currentState)
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.ReadResource_Response{NewState: dynamicValue(is)}, nil
+	newState, err := dynamicValueFromState(is, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ReadResource_Response{NewState: newState}, nil
 }
 
 func (s *GRPCResourceProviderServer) PlanResourceChange(_ context.Context, req *proto.PlanResourceChange_Request) (*proto.PlanResourceChange_Response, error) {
-	info := &terraform.InstanceInfo{}
-	state := &terraform.InstanceState{}
-	cfg := &terraform.ResourceConfig{}
+	schema, err := s.schemaFor(req.ResourceTypeName, false)
+	if err != nil {
+		return nil, err
+	}
 
-	unDynamicValue(req.PriorState, state)
-	unDynamicValue(req.ProposedNewState, cfg)
+	state, err := stateFromDynamicValue(req.PriorState, 0, schema)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := configFromDynamicValue(req.ProposedNewState, schema)
+	if err != nil {
+		return nil, err
+	}
 
-	info.Type = req.ResourceTypeName
+	info := &terraform.InstanceInfo{Type: req.ResourceTypeName}
 
 	d, err := s.provider.Diff(info, state, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.PlanResourceChange_Response{PlannedNewState: dynamicValue(d)}, nil
+	// The response carries a legacy InstanceDiff, not a cty.Value: see the
+	// matching comment on the client's Diff method.
+	plannedNewState, err := dynamicValue(d)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.PlanResourceChange_Response{PlannedNewState: plannedNewState}, nil
 }
 
-func (s *GRPCResourceProviderServer) ApplyResourceChange(_ context.Context, req *proto.ApplyResourceChange_Request) (*proto.ApplyResourceChange_Response, error) {
+// ApplyResourceChange streams its result as a sequence of
+// ApplyResourceChange_Response events rather than returning a single
+// response, so that the client doesn't have to wait for the whole apply to
+// finish before it sees any diagnostics. Today the wrapped provider only
+// gives us diagnostics and a final state once Apply itself returns, so we
+// only ever send a single event, but the client is already written to
+// consume an arbitrary-length stream so a future provider-facing hook that
+// reports partial progress can start sending more without a protocol
+// change.
+func (s *GRPCResourceProviderServer) ApplyResourceChange(req *proto.ApplyResourceChange_Request, server proto.Provider_ApplyResourceChangeServer) error {
+	schema, err := s.schemaFor(req.ResourceTypeName, false)
+	if err != nil {
+		return err
+	}
 
-	info := &terraform.InstanceInfo{}
-	state := &terraform.InstanceState{}
+	state, err := stateFromDynamicValue(req.PriorState, 0, schema)
+	if err != nil {
+		return err
+	}
+	// PlannedNewState still carries a legacy InstanceDiff; see the matching
+	// comment on the client's Apply method.
 	diff := &terraform.InstanceDiff{}
+	if err := unDynamicValue(req.PlannedNewState, diff); err != nil {
+		return err
+	}
+
+	info := &terraform.InstanceInfo{Type: req.ResourceTypeName}
+
+	is, err := s.provider.Apply(info, state, diff)
+	if err != nil {
+		return err
+	}
+
+	newState, err := dynamicValueFromState(is, schema)
+	if err != nil {
+		return err
+	}
+	return server.Send(&proto.ApplyResourceChange_Response{NewState: newState})
+}
+
+// ApplyResourceChangeUnary is the non-streaming fallback for providers
+// whose clients haven't been updated to consume the streaming form of
+// ApplyResourceChange.
+func (s *GRPCResourceProviderServer) ApplyResourceChangeUnary(_ context.Context, req *proto.ApplyResourceChange_Request) (*proto.ApplyResourceChange_Response, error) {
+	schema, err := s.schemaFor(req.ResourceTypeName, false)
+	if err != nil {
+		return nil, err
+	}
 
-	unDynamicValue(req.PriorState, state)
-	unDynamicValue(req.PlannedNewState, diff)
+	state, err := stateFromDynamicValue(req.PriorState, 0, schema)
+	if err != nil {
+		return nil, err
+	}
+	diff := &terraform.InstanceDiff{}
+	if err := unDynamicValue(req.PlannedNewState, diff); err != nil {
+		return nil, err
+	}
 
-	info.Type = req.ResourceTypeName
+	info := &terraform.InstanceInfo{Type: req.ResourceTypeName}
 
 	is, err := s.provider.Apply(info, state, diff)
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.ApplyResourceChange_Response{NewState: dynamicValue(is)}, nil
+	newState, err := dynamicValueFromState(is, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ApplyResourceChange_Response{NewState: newState}, nil
 }
 
 func (s *GRPCResourceProviderServer) ImportResourceState(_ context.Context, req *proto.ImportResourceState_Request) (*proto.ImportResourceState_Response, error) {
-	info := &terraform.InstanceInfo{}
-	info.Type = req.ResourceTypeName
+	schema, err := s.schemaFor(req.ResourceTypeName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &terraform.InstanceInfo{Type: req.ResourceTypeName}
 
 	states, err := s.provider.ImportState(info, req.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.ImportResourceState_Response{NewState: dynamicValue(states)}, nil
+	dynamicStates := make([]*proto.DynamicValue, len(states))
+	for i, is := range states {
+		v, err := dynamicValueFromState(is, schema)
+		if err != nil {
+			return nil, err
+		}
+		dynamicStates[i] = v
+	}
+
+	newState, err := dynamicValue(dynamicStates)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ImportResourceState_Response{NewState: newState}, nil
 }
 
 func (s *GRPCResourceProviderServer) ValidateDataSourceConfig(_ context.Context, req *proto.ValidateDataSourceConfig_Request) (*proto.ValidateDataSourceConfig_Response, error) {
-	cfg := &terraform.ResourceConfig{}
-	unDynamicValue(req.Config, cfg)
+	schema, err := s.schemaFor(req.DataSourceName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := configFromDynamicValue(req.Config, schema)
+	if err != nil {
+		return nil, err
+	}
 
-	w, e := s.provider.ValidateDataSource(req.DataSourceName, cfg)
-	return &proto.ValidateDataSourceConfig_Response{Diagnostics: diagnostics(w, e)}, nil
+	diags := s.provider.ValidateDataSource(req.DataSourceName, cfg)
+	return &proto.ValidateDataSourceConfig_Response{Diagnostics: proto.NewDiagnostics(diags)}, nil
 }
 
 func (s *GRPCResourceProviderServer) TempDiffDataSource(_ context.Context, req *proto.ReadDataSource_Request) (*proto.ReadDataSource_Response, error) {
@@ -432,14 +1088,20 @@ func (s *GRPCResourceProviderServer) TempDiffDataSource(_ context.Context, req *
 	info.Type = req.DataSourceName
 
 	cfg := &terraform.ResourceConfig{}
-	unDynamicValue(req.Request, cfg)
+	if err := unDynamicValue(req.Request, cfg); err != nil {
+		return nil, err
+	}
 
 	diff, err := s.provider.ReadDataDiff(info, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.ReadDataSource_Response{Result: dynamicValue(diff)}, nil
+	result, err := dynamicValue(diff)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ReadDataSource_Response{Result: result}, nil
 }
 
 func (s *GRPCResourceProviderServer) ReadDataSource(_ context.Context, req *proto.ReadDataSource_Request) (*proto.ReadDataSource_Response, error) {
@@ -447,18 +1109,49 @@ func (s *GRPCResourceProviderServer) ReadDataSource(_ context.Context, req *prot
 	info.Type = req.DataSourceName
 
 	diff := &terraform.InstanceDiff{}
-	unDynamicValue(req.Request, diff)
+	if err := unDynamicValue(req.Request, diff); err != nil {
+		return nil, err
+	}
 
 	state, err := s.provider.ReadDataApply(info, diff)
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.ReadDataSource_Response{Result: dynamicValue(state)}, nil
+	schema, err := s.schemaFor(req.DataSourceName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := dynamicValueFromState(state, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ReadDataSource_Response{Result: result}, nil
 }
 
-func (s *GRPCResourceProviderServer) UpgradeResourceState(_ context.Context, _ *proto.UpgradeResourceState_Request) (*proto.UpgradeResourceState_Response, error) {
-	return &proto.UpgradeResourceState_Response{}, nil
+func (s *GRPCResourceProviderServer) UpgradeResourceState(_ context.Context, req *proto.UpgradeResourceState_Request) (*proto.UpgradeResourceState_Response, error) {
+	var raw []byte
+	if req.RawState != nil {
+		raw = req.RawState.Json
+	}
+
+	info := &terraform.InstanceInfo{Type: req.ResourceTypeName}
+	state, err := s.provider.UpgradeState(info, raw, int(req.Version))
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := s.schemaFor(req.ResourceTypeName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	upgraded, err := dynamicValueFromState(state, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.UpgradeResourceState_Response{UpgradedState: upgraded}, nil
 }
 
 func (s *GRPCResourceProviderServer) Stop(_ context.Context, _ *proto.Stop_Request) (*proto.Stop_Response, error) {
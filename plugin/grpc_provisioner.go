@@ -16,9 +16,26 @@ type GRPCResourceProvisioner struct {
 	client proto.ProvisionerClient
 }
 
+// StructuredUIOutput is an optional extension of terraform.UIOutput that a
+// caller of Apply can implement to receive structured events instead of only
+// plain strings. Each event carries a log level and a message and nothing
+// else: there are no separate stdout/stderr streams, progress percentages,
+// structured fields or terminal status. Callers that don't
+// implement it keep working exactly as before: GRPCResourceProvisioner
+// falls back to Output with the event's message.
+type StructuredUIOutput interface {
+	terraform.UIOutput
+	StructuredOutput(event *proto.ProvisionerApply_Event)
+}
+
 func (p *GRPCResourceProvisioner) Validate(c *terraform.ResourceConfig) ([]string, []error) {
+	config, err := dynamicValue(c)
+	if err != nil {
+		return nil, []error{err}
+	}
+
 	req := &proto.ValidateProvisionerConfig_Request{
-		Config: dynamicValue(c),
+		Config: config,
 	}
 	resp, err := p.client.ValidateProvisionerConfig(context.TODO(), req)
 	if err != nil {
@@ -37,8 +54,13 @@ func (p *GRPCResourceProvisioner) Apply(out terraform.UIOutput, s *terraform.Ins
 		Config: c,
 	}
 
+	config, err := dynamicValue(payload)
+	if err != nil {
+		return err
+	}
+
 	req := &proto.ProvisionerApply_Request{
-		Config: dynamicValue(payload),
+		Config: config,
 	}
 
 	outputClient, err := p.client.Apply(context.TODO(), req)
@@ -46,10 +68,29 @@ func (p *GRPCResourceProvisioner) Apply(out terraform.UIOutput, s *terraform.Ins
 		return err
 	}
 
+	structuredOut, supportsStructured := out.(StructuredUIOutput)
+
 	for {
 		resp, err := outputClient.Recv()
 		if resp != nil {
-			out.Output(resp.Output)
+			switch {
+			case resp.Event != nil && supportsStructured:
+				structuredOut.StructuredOutput(resp.Event)
+			case resp.Event != nil:
+				// The caller only understands plain output, so just surface
+				// the event's human-readable message.
+				out.Output(resp.Event.Message)
+			case supportsStructured:
+				// An older provisioner that only ever sends plain strings;
+				// synthesize an INFO-level event so the caller still gets a
+				// consistent structured stream.
+				structuredOut.StructuredOutput(&proto.ProvisionerApply_Event{
+					Level:   proto.ProvisionerApply_Event_INFO,
+					Message: resp.Output,
+				})
+			default:
+				out.Output(resp.Output)
+			}
 		}
 		if err != nil {
 			if err == io.EOF {
@@ -83,7 +124,9 @@ type GRPCResourceProvisionerServer struct {
 
 func (s *GRPCResourceProvisionerServer) ValidateProvisionerConfig(_ context.Context, req *proto.ValidateProvisionerConfig_Request) (*proto.ValidateProvisionerConfig_Response, error) {
 	cfg := &terraform.ResourceConfig{}
-	unDynamicValue(req.Config, cfg)
+	if err := unDynamicValue(req.Config, cfg); err != nil {
+		return nil, err
+	}
 
 	w, e := s.provisioner.Validate(cfg)
 	return &proto.ValidateProvisionerConfig_Response{Diagnostics: diagnostics(w, e)}, nil
@@ -95,7 +138,9 @@ func (s *GRPCResourceProvisionerServer) Apply(req *proto.ProvisionerApply_Reques
 		Config *terraform.ResourceConfig
 	}{}
 
-	unDynamicValue(req.Config, &payload)
+	if err := unDynamicValue(req.Config, &payload); err != nil {
+		return err
+	}
 
 	return s.provisioner.Apply(&grpcOutputServer{server: server}, payload.State, payload.Config)
 }
@@ -113,6 +158,12 @@ type grpcOutputServer struct {
 	server proto.Provisioner_ApplyServer
 }
 
+var _ StructuredUIOutput = (*grpcOutputServer)(nil)
+
 func (s *grpcOutputServer) Output(msg string) {
 	s.server.Send(&proto.ProvisionerApply_Response{Output: msg})
 }
+
+func (s *grpcOutputServer) StructuredOutput(event *proto.ProvisionerApply_Event) {
+	s.server.Send(&proto.ProvisionerApply_Response{Event: event})
+}
@@ -0,0 +1,76 @@
+package proto
+
+// The types below back NewDiagnostic/TFDiagnostics in diagnostics.go. In a
+// full build they'd come out of protoc-gen-go from the plugin .proto source;
+// since this tree has no protoc tooling, they're hand-written to the same
+// shape generated code would produce, including AttributePathStep's oneof
+// modeled as an interface with one implementing type per alternative.
+
+// Diagnostic_Level is the severity of a Diagnostic.
+type Diagnostic_Level int32
+
+const (
+	Diagnostic_INVALID Diagnostic_Level = 0
+	Diagnostic_ERROR   Diagnostic_Level = 1
+	Diagnostic_WARNING Diagnostic_Level = 2
+)
+
+// Diagnostic is a single warning or error produced by a provider,
+// optionally attributed to a source location or a specific attribute within
+// the configuration it was raised against.
+type Diagnostic struct {
+	Level         Diagnostic_Level
+	Summary       string
+	Detail        string
+	Range         *Diagnostic_Range
+	AttributePath []*AttributePathStep
+}
+
+// Diagnostic_Range identifies a span of source configuration a Diagnostic
+// applies to.
+type Diagnostic_Range struct {
+	Filename string
+	Start    *Diagnostic_Pos
+	End      *Diagnostic_Pos
+}
+
+// Diagnostic_Pos is a single position within a Diagnostic_Range.
+type Diagnostic_Pos struct {
+	Line   int64
+	Column int64
+	Byte   int64
+}
+
+// AttributePathStep is one step of a path identifying a specific attribute
+// within a resource's configuration, such as the "foo" in a path pointing at
+// block.foo or list[2]. Selector holds exactly one of the
+// AttributePathStep_* types below.
+type AttributePathStep struct {
+	Selector isAttributePathStep_Selector
+}
+
+type isAttributePathStep_Selector interface {
+	isAttributePathStep_Selector()
+}
+
+// AttributePathStep_AttributeName selects a named attribute or block, e.g.
+// the ".foo" in a path like block.foo.
+type AttributePathStep_AttributeName struct {
+	AttributeName string
+}
+
+// AttributePathStep_ElementKeyString selects an element of a map or set by
+// string key.
+type AttributePathStep_ElementKeyString struct {
+	ElementKeyString string
+}
+
+// AttributePathStep_ElementKeyInt selects an element of a list or set by
+// integer index.
+type AttributePathStep_ElementKeyInt struct {
+	ElementKeyInt int64
+}
+
+func (*AttributePathStep_AttributeName) isAttributePathStep_Selector()    {}
+func (*AttributePathStep_ElementKeyString) isAttributePathStep_Selector() {}
+func (*AttributePathStep_ElementKeyInt) isAttributePathStep_Selector()    {}
@@ -3,9 +3,19 @@ package proto
 import (
 	"errors"
 
+	hcl2 "github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
 )
 
+// attributeDiagnostic is implemented by tfdiags.Diagnostic values produced
+// by tfdiags.AttributeValue, letting us recover the attribute path when
+// converting to the wire format without tfdiags.Diagnostic itself needing
+// to expose it.
+type attributeDiagnostic interface {
+	AttributePath() cty.Path
+}
+
 func NewDiagnostic(d tfdiags.Diagnostic) *Diagnostic {
 	result := &Diagnostic{}
 	switch d.Severity() {
@@ -18,6 +28,15 @@ func NewDiagnostic(d tfdiags.Diagnostic) *Diagnostic {
 	desc := d.Description()
 	result.Summary = desc.Summary
 	result.Detail = desc.Detail
+
+	if src := d.Source(); src.Subject != nil {
+		result.Range = newDiagnosticRange(src.Subject)
+	}
+
+	if attr, ok := d.(attributeDiagnostic); ok {
+		result.AttributePath = newAttributePath(attr.AttributePath())
+	}
+
 	return result
 }
 
@@ -32,12 +51,116 @@ func NewDiagnostics(ds tfdiags.Diagnostics) []*Diagnostic {
 func TFDiagnostics(ds []*Diagnostic) tfdiags.Diagnostics {
 	var result tfdiags.Diagnostics
 	for _, d := range ds {
-		switch d.Level {
-		case Diagnostic_WARNING:
-			result = result.Append(tfdiags.SimpleWarning(d.Summary))
-		case Diagnostic_ERROR:
-			result = result.Append(errors.New(d.Summary))
+		result = result.Append(tfDiagnostic(d))
+	}
+	return result
+}
+
+func tfDiagnostic(d *Diagnostic) tfdiags.Diagnostic {
+	if len(d.AttributePath) > 0 {
+		severity := tfdiags.Error
+		if d.Level == Diagnostic_WARNING {
+			severity = tfdiags.Warning
+		}
+		return tfdiags.AttributeValue(severity, d.Summary, d.Detail, ctyPath(d.AttributePath))
+	}
+
+	if d.Range != nil {
+		severity := hcl2.DiagError
+		if d.Level == Diagnostic_WARNING {
+			severity = hcl2.DiagWarning
+		}
+		return &hcl2.Diagnostic{
+			Severity: severity,
+			Summary:  d.Summary,
+			Detail:   d.Detail,
+			Subject:  diagnosticRangeSubject(d.Range),
+		}
+	}
+
+	if d.Level == Diagnostic_WARNING {
+		return tfdiags.SimpleWarning(d.Summary)
+	}
+	return tfdiags.FromError(errors.New(d.Summary))
+}
+
+// newDiagnosticRange converts an HCL2 source range, as found on a
+// tfdiags.Diagnostic's Source().Subject, into the equivalent proto message
+// so that the file:line:col a provider-originated diagnostic points at
+// survives the trip across the plugin boundary.
+func newDiagnosticRange(rng *hcl2.Range) *Diagnostic_Range {
+	return &Diagnostic_Range{
+		Filename: rng.Filename,
+		Start:    newDiagnosticPos(rng.Start),
+		End:      newDiagnosticPos(rng.End),
+	}
+}
+
+func newDiagnosticPos(pos hcl2.Pos) *Diagnostic_Pos {
+	return &Diagnostic_Pos{
+		Line:   int64(pos.Line),
+		Column: int64(pos.Column),
+		Byte:   int64(pos.Byte),
+	}
+}
+
+func diagnosticRangeSubject(rng *Diagnostic_Range) *hcl2.Range {
+	return &hcl2.Range{
+		Filename: rng.Filename,
+		Start:    diagnosticPos(rng.Start),
+		End:      diagnosticPos(rng.End),
+	}
+}
+
+func diagnosticPos(pos *Diagnostic_Pos) hcl2.Pos {
+	if pos == nil {
+		return hcl2.Pos{}
+	}
+	return hcl2.Pos{
+		Line:   int(pos.Line),
+		Column: int(pos.Column),
+		Byte:   int(pos.Byte),
+	}
+}
+
+// newAttributePath converts a cty.Path, identifying an attribute within a
+// resource configuration, into the equivalent sequence of proto steps.
+func newAttributePath(path cty.Path) []*AttributePathStep {
+	var result []*AttributePathStep
+	for _, step := range path {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			result = append(result, &AttributePathStep{
+				Selector: &AttributePathStep_AttributeName{AttributeName: s.Name},
+			})
+		case cty.IndexStep:
+			switch s.Key.Type() {
+			case cty.String:
+				result = append(result, &AttributePathStep{
+					Selector: &AttributePathStep_ElementKeyString{ElementKeyString: s.Key.AsString()},
+				})
+			case cty.Number:
+				idx, _ := s.Key.AsBigFloat().Int64()
+				result = append(result, &AttributePathStep{
+					Selector: &AttributePathStep_ElementKeyInt{ElementKeyInt: idx},
+				})
+			}
 		}
 	}
 	return result
 }
+
+func ctyPath(steps []*AttributePathStep) cty.Path {
+	var path cty.Path
+	for _, step := range steps {
+		switch s := step.Selector.(type) {
+		case *AttributePathStep_AttributeName:
+			path = append(path, cty.GetAttrStep{Name: s.AttributeName})
+		case *AttributePathStep_ElementKeyString:
+			path = append(path, cty.IndexStep{Key: cty.StringVal(s.ElementKeyString)})
+		case *AttributePathStep_ElementKeyInt:
+			path = append(path, cty.IndexStep{Key: cty.NumberIntVal(s.ElementKeyInt)})
+		}
+	}
+	return path
+}
@@ -0,0 +1,151 @@
+package proto
+
+// DynamicValue is an opaque, schema-shaped payload. A provider and core
+// agree out of band (via the resource's schema) on how to interpret it:
+// either as cty/msgpack-encoded bytes in Msgpack, or as plain JSON in Json,
+// used for state written before a schema-typed encoding existed.
+type DynamicValue struct {
+	Msgpack []byte
+	Json    []byte
+}
+
+type Stop_Request struct{}
+
+type Stop_Response struct {
+	Error string
+}
+
+type GetSchema_Request struct{}
+
+type GetSchema_Response struct {
+	// ProviderSchema carries the provider's full schema (provider config
+	// block plus every resource type and data source block), msgpack-encoded
+	// as a terraform.ProviderSchema; it isn't itself schema-shaped, so it
+	// travels as a DynamicValue the same way the legacy-shaped RPCs below do.
+	ProviderSchema *DynamicValue
+
+	// ServerCapabilities advertises which optional RPCs this provider
+	// implements, so a client built against a newer protocol version can
+	// tell which of them are safe to rely on instead of discovering their
+	// absence by calling one and hitting an Unimplemented error.
+	ServerCapabilities *ServerCapabilities
+}
+
+// ServerCapabilities describes which optional RPCs a provider plugin
+// implements. It's returned once, as part of GetSchema's response, since a
+// provider's capabilities can't change without restarting the plugin
+// process.
+type ServerCapabilities struct {
+	// ProtocolVersion is the plugin protocol version this server was built
+	// against, independent of the handshake protocol version negotiated by
+	// go-plugin itself.
+	ProtocolVersion int64
+
+	// StreamingApply indicates the server implements the streaming form of
+	// ApplyResourceChange rather than only ApplyResourceChangeUnary.
+	StreamingApply bool
+
+	// UpgradeState indicates the server implements UpgradeResourceState.
+	UpgradeState bool
+
+	// PlanDestroy indicates the server can plan a destroy change via
+	// PlanResourceChange rather than requiring core to synthesize one.
+	PlanDestroy bool
+}
+
+type ValidateProviderConfig_Request struct {
+	Config *DynamicValue
+}
+
+type ValidateProviderConfig_Response struct {
+	Diagnostics []*Diagnostic
+}
+
+type ValidateResourceTypeConfig_Request struct {
+	ResourceTypeName string
+	Config           *DynamicValue
+}
+
+type ValidateResourceTypeConfig_Response struct {
+	Diagnostics []*Diagnostic
+}
+
+type ValidateDataSourceConfig_Request struct {
+	DataSourceName string
+	Config         *DynamicValue
+}
+
+type ValidateDataSourceConfig_Response struct {
+	Diagnostics []*Diagnostic
+}
+
+type Configure_Request struct {
+	Config *DynamicValue
+}
+
+type Configure_Response struct {
+	Diagnostics []*Diagnostic
+}
+
+type ReadResource_Request struct {
+	ResourceTypeName string
+	CurrentState     *DynamicValue
+}
+
+type ReadResource_Response struct {
+	NewState    *DynamicValue
+	Diagnostics []*Diagnostic
+}
+
+type PlanResourceChange_Request struct {
+	ResourceTypeName string
+	PriorState       *DynamicValue
+	ProposedNewState *DynamicValue
+}
+
+type PlanResourceChange_Response struct {
+	PlannedNewState *DynamicValue
+	Diagnostics     []*Diagnostic
+}
+
+type ApplyResourceChange_Request struct {
+	ResourceTypeName string
+	PriorState       *DynamicValue
+	PlannedNewState  *DynamicValue
+}
+
+type ApplyResourceChange_Response struct {
+	NewState    *DynamicValue
+	Diagnostics []*Diagnostic
+}
+
+type ImportResourceState_Request struct {
+	ResourceTypeName string
+	Id               string
+}
+
+type ImportResourceState_Response struct {
+	NewState    *DynamicValue
+	Diagnostics []*Diagnostic
+}
+
+type UpgradeResourceState_Request struct {
+	ResourceTypeName string
+	Version          int64
+	RawState         *DynamicValue
+}
+
+type UpgradeResourceState_Response struct {
+	UpgradedState *DynamicValue
+	Diagnostics   []*Diagnostic
+}
+
+type ReadDataSource_Request struct {
+	DataSourceName string
+	Request        *DynamicValue
+}
+
+type ReadDataSource_Response struct {
+	Result      *DynamicValue
+	Diagnostics []*Diagnostic
+}
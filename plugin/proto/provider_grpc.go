@@ -0,0 +1,358 @@
+package proto
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ProviderClient is the client API for the Provider plugin service.
+type ProviderClient interface {
+	GetSchema(ctx context.Context, in *GetSchema_Request, opts ...grpc.CallOption) (*GetSchema_Response, error)
+	ValidateProviderConfig(ctx context.Context, in *ValidateProviderConfig_Request, opts ...grpc.CallOption) (*ValidateProviderConfig_Response, error)
+	ValidateResourceTypeConfig(ctx context.Context, in *ValidateResourceTypeConfig_Request, opts ...grpc.CallOption) (*ValidateResourceTypeConfig_Response, error)
+	ValidateDataSourceConfig(ctx context.Context, in *ValidateDataSourceConfig_Request, opts ...grpc.CallOption) (*ValidateDataSourceConfig_Response, error)
+	Configure(ctx context.Context, in *Configure_Request, opts ...grpc.CallOption) (*Configure_Response, error)
+	ReadResource(ctx context.Context, in *ReadResource_Request, opts ...grpc.CallOption) (*ReadResource_Response, error)
+	PlanResourceChange(ctx context.Context, in *PlanResourceChange_Request, opts ...grpc.CallOption) (*PlanResourceChange_Response, error)
+	// ApplyResourceChange streams its result as a sequence of
+	// ApplyResourceChange_Response events rather than returning a single
+	// response, so a long-running apply's diagnostics arrive as soon as the
+	// provider sends them. ApplyResourceChangeUnary is the non-streaming
+	// fallback for providers that don't implement the streaming form.
+	ApplyResourceChange(ctx context.Context, in *ApplyResourceChange_Request, opts ...grpc.CallOption) (Provider_ApplyResourceChangeClient, error)
+	ApplyResourceChangeUnary(ctx context.Context, in *ApplyResourceChange_Request, opts ...grpc.CallOption) (*ApplyResourceChange_Response, error)
+	ImportResourceState(ctx context.Context, in *ImportResourceState_Request, opts ...grpc.CallOption) (*ImportResourceState_Response, error)
+	UpgradeResourceState(ctx context.Context, in *UpgradeResourceState_Request, opts ...grpc.CallOption) (*UpgradeResourceState_Response, error)
+	TempDiffDataSource(ctx context.Context, in *ReadDataSource_Request, opts ...grpc.CallOption) (*ReadDataSource_Response, error)
+	ReadDataSource(ctx context.Context, in *ReadDataSource_Request, opts ...grpc.CallOption) (*ReadDataSource_Response, error)
+	Stop(ctx context.Context, in *Stop_Request, opts ...grpc.CallOption) (*Stop_Response, error)
+}
+
+type providerClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewProviderClient returns a ProviderClient that issues RPCs over cc.
+func NewProviderClient(cc *grpc.ClientConn) ProviderClient {
+	return &providerClient{cc: cc}
+}
+
+func (c *providerClient) GetSchema(ctx context.Context, in *GetSchema_Request, opts ...grpc.CallOption) (*GetSchema_Response, error) {
+	out := new(GetSchema_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/GetSchema", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) ValidateProviderConfig(ctx context.Context, in *ValidateProviderConfig_Request, opts ...grpc.CallOption) (*ValidateProviderConfig_Response, error) {
+	out := new(ValidateProviderConfig_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/ValidateProviderConfig", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) ValidateResourceTypeConfig(ctx context.Context, in *ValidateResourceTypeConfig_Request, opts ...grpc.CallOption) (*ValidateResourceTypeConfig_Response, error) {
+	out := new(ValidateResourceTypeConfig_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/ValidateResourceTypeConfig", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) ValidateDataSourceConfig(ctx context.Context, in *ValidateDataSourceConfig_Request, opts ...grpc.CallOption) (*ValidateDataSourceConfig_Response, error) {
+	out := new(ValidateDataSourceConfig_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/ValidateDataSourceConfig", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) Configure(ctx context.Context, in *Configure_Request, opts ...grpc.CallOption) (*Configure_Response, error) {
+	out := new(Configure_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/Configure", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) ReadResource(ctx context.Context, in *ReadResource_Request, opts ...grpc.CallOption) (*ReadResource_Response, error) {
+	out := new(ReadResource_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/ReadResource", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) PlanResourceChange(ctx context.Context, in *PlanResourceChange_Request, opts ...grpc.CallOption) (*PlanResourceChange_Response, error) {
+	out := new(PlanResourceChange_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/PlanResourceChange", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) ApplyResourceChange(ctx context.Context, in *ApplyResourceChange_Request, opts ...grpc.CallOption) (Provider_ApplyResourceChangeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Provider_serviceDesc.Streams[0], "/plugin.Provider/ApplyResourceChange", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &providerApplyResourceChangeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Provider_ApplyResourceChangeClient is the client-side handle on the
+// ApplyResourceChange response stream: one Recv per event the server sends,
+// terminated by io.EOF.
+type Provider_ApplyResourceChangeClient interface {
+	Recv() (*ApplyResourceChange_Response, error)
+	grpc.ClientStream
+}
+
+type providerApplyResourceChangeClient struct {
+	grpc.ClientStream
+}
+
+func (x *providerApplyResourceChangeClient) Recv() (*ApplyResourceChange_Response, error) {
+	m := new(ApplyResourceChange_Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *providerClient) ApplyResourceChangeUnary(ctx context.Context, in *ApplyResourceChange_Request, opts ...grpc.CallOption) (*ApplyResourceChange_Response, error) {
+	out := new(ApplyResourceChange_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/ApplyResourceChangeUnary", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) ImportResourceState(ctx context.Context, in *ImportResourceState_Request, opts ...grpc.CallOption) (*ImportResourceState_Response, error) {
+	out := new(ImportResourceState_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/ImportResourceState", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) UpgradeResourceState(ctx context.Context, in *UpgradeResourceState_Request, opts ...grpc.CallOption) (*UpgradeResourceState_Response, error) {
+	out := new(UpgradeResourceState_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/UpgradeResourceState", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) TempDiffDataSource(ctx context.Context, in *ReadDataSource_Request, opts ...grpc.CallOption) (*ReadDataSource_Response, error) {
+	out := new(ReadDataSource_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/TempDiffDataSource", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) ReadDataSource(ctx context.Context, in *ReadDataSource_Request, opts ...grpc.CallOption) (*ReadDataSource_Response, error) {
+	out := new(ReadDataSource_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/ReadDataSource", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) Stop(ctx context.Context, in *Stop_Request, opts ...grpc.CallOption) (*Stop_Response, error) {
+	out := new(Stop_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provider/Stop", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProviderServer is the server API for the Provider plugin service.
+type ProviderServer interface {
+	GetSchema(context.Context, *GetSchema_Request) (*GetSchema_Response, error)
+	ValidateProviderConfig(context.Context, *ValidateProviderConfig_Request) (*ValidateProviderConfig_Response, error)
+	ValidateResourceTypeConfig(context.Context, *ValidateResourceTypeConfig_Request) (*ValidateResourceTypeConfig_Response, error)
+	ValidateDataSourceConfig(context.Context, *ValidateDataSourceConfig_Request) (*ValidateDataSourceConfig_Response, error)
+	Configure(context.Context, *Configure_Request) (*Configure_Response, error)
+	ReadResource(context.Context, *ReadResource_Request) (*ReadResource_Response, error)
+	PlanResourceChange(context.Context, *PlanResourceChange_Request) (*PlanResourceChange_Response, error)
+	ApplyResourceChange(*ApplyResourceChange_Request, Provider_ApplyResourceChangeServer) error
+	ApplyResourceChangeUnary(context.Context, *ApplyResourceChange_Request) (*ApplyResourceChange_Response, error)
+	ImportResourceState(context.Context, *ImportResourceState_Request) (*ImportResourceState_Response, error)
+	UpgradeResourceState(context.Context, *UpgradeResourceState_Request) (*UpgradeResourceState_Response, error)
+	TempDiffDataSource(context.Context, *ReadDataSource_Request) (*ReadDataSource_Response, error)
+	ReadDataSource(context.Context, *ReadDataSource_Request) (*ReadDataSource_Response, error)
+	Stop(context.Context, *Stop_Request) (*Stop_Response, error)
+}
+
+func _Provider_GetSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchema_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).GetSchema(ctx, in)
+}
+
+func _Provider_ValidateProviderConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateProviderConfig_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).ValidateProviderConfig(ctx, in)
+}
+
+func _Provider_ValidateResourceTypeConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateResourceTypeConfig_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).ValidateResourceTypeConfig(ctx, in)
+}
+
+func _Provider_ValidateDataSourceConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateDataSourceConfig_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).ValidateDataSourceConfig(ctx, in)
+}
+
+func _Provider_Configure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Configure_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).Configure(ctx, in)
+}
+
+func _Provider_ReadResource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadResource_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).ReadResource(ctx, in)
+}
+
+func _Provider_PlanResourceChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanResourceChange_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).PlanResourceChange(ctx, in)
+}
+
+func _Provider_ApplyResourceChange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(ApplyResourceChange_Request)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(ProviderServer).ApplyResourceChange(in, &providerApplyResourceChangeServer{stream})
+}
+
+// Provider_ApplyResourceChangeServer is the server-side handle on the
+// ApplyResourceChange response stream: the implementation calls Send once
+// per event it wants to deliver before returning.
+type Provider_ApplyResourceChangeServer interface {
+	Send(*ApplyResourceChange_Response) error
+	grpc.ServerStream
+}
+
+type providerApplyResourceChangeServer struct {
+	grpc.ServerStream
+}
+
+func (x *providerApplyResourceChangeServer) Send(m *ApplyResourceChange_Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Provider_ApplyResourceChangeUnary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApplyResourceChange_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).ApplyResourceChangeUnary(ctx, in)
+}
+
+func _Provider_ImportResourceState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportResourceState_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).ImportResourceState(ctx, in)
+}
+
+func _Provider_UpgradeResourceState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpgradeResourceState_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).UpgradeResourceState(ctx, in)
+}
+
+func _Provider_TempDiffDataSource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadDataSource_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).TempDiffDataSource(ctx, in)
+}
+
+func _Provider_ReadDataSource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadDataSource_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).ReadDataSource(ctx, in)
+}
+
+func _Provider_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Stop_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProviderServer).Stop(ctx, in)
+}
+
+// RegisterProviderServer registers srv with s so incoming RPCs are routed
+// to it.
+func RegisterProviderServer(s *grpc.Server, srv ProviderServer) {
+	s.RegisterService(&_Provider_serviceDesc, srv)
+}
+
+var _Provider_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "plugin.Provider",
+	HandlerType: (*ProviderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "GetSchema", Handler: _Provider_GetSchema_Handler},
+		{MethodName: "ValidateProviderConfig", Handler: _Provider_ValidateProviderConfig_Handler},
+		{MethodName: "ValidateResourceTypeConfig", Handler: _Provider_ValidateResourceTypeConfig_Handler},
+		{MethodName: "ValidateDataSourceConfig", Handler: _Provider_ValidateDataSourceConfig_Handler},
+		{MethodName: "Configure", Handler: _Provider_Configure_Handler},
+		{MethodName: "ReadResource", Handler: _Provider_ReadResource_Handler},
+		{MethodName: "PlanResourceChange", Handler: _Provider_PlanResourceChange_Handler},
+		{MethodName: "ApplyResourceChangeUnary", Handler: _Provider_ApplyResourceChangeUnary_Handler},
+		{MethodName: "ImportResourceState", Handler: _Provider_ImportResourceState_Handler},
+		{MethodName: "UpgradeResourceState", Handler: _Provider_UpgradeResourceState_Handler},
+		{MethodName: "TempDiffDataSource", Handler: _Provider_TempDiffDataSource_Handler},
+		{MethodName: "ReadDataSource", Handler: _Provider_ReadDataSource_Handler},
+		{MethodName: "Stop", Handler: _Provider_Stop_Handler},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ApplyResourceChange",
+			Handler:       _Provider_ApplyResourceChange_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "plugin.proto",
+}
@@ -0,0 +1,147 @@
+package proto
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ProvisionerClient is the client API for the Provisioner plugin service.
+type ProvisionerClient interface {
+	ValidateProvisionerConfig(ctx context.Context, in *ValidateProvisionerConfig_Request, opts ...grpc.CallOption) (*ValidateProvisionerConfig_Response, error)
+	// Apply streams its result as a sequence of ProvisionerApply_Response
+	// events rather than returning a single response, so output is
+	// delivered to the caller as the provisioner produces it instead of
+	// only once the whole run finishes.
+	Apply(ctx context.Context, in *ProvisionerApply_Request, opts ...grpc.CallOption) (Provisioner_ApplyClient, error)
+	Stop(ctx context.Context, in *Stop_Request, opts ...grpc.CallOption) (*Stop_Response, error)
+}
+
+type provisionerClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewProvisionerClient returns a ProvisionerClient that issues RPCs over cc.
+func NewProvisionerClient(cc *grpc.ClientConn) ProvisionerClient {
+	return &provisionerClient{cc: cc}
+}
+
+func (c *provisionerClient) ValidateProvisionerConfig(ctx context.Context, in *ValidateProvisionerConfig_Request, opts ...grpc.CallOption) (*ValidateProvisionerConfig_Response, error) {
+	out := new(ValidateProvisionerConfig_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provisioner/ValidateProvisionerConfig", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *provisionerClient) Apply(ctx context.Context, in *ProvisionerApply_Request, opts ...grpc.CallOption) (Provisioner_ApplyClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Provisioner_serviceDesc.Streams[0], "/plugin.Provisioner/Apply", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &provisionerApplyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Provisioner_ApplyClient is the client-side handle on the Apply response
+// stream: one Recv per event the provisioner sends, terminated by io.EOF.
+type Provisioner_ApplyClient interface {
+	Recv() (*ProvisionerApply_Response, error)
+	grpc.ClientStream
+}
+
+type provisionerApplyClient struct {
+	grpc.ClientStream
+}
+
+func (x *provisionerApplyClient) Recv() (*ProvisionerApply_Response, error) {
+	m := new(ProvisionerApply_Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *provisionerClient) Stop(ctx context.Context, in *Stop_Request, opts ...grpc.CallOption) (*Stop_Response, error) {
+	out := new(Stop_Response)
+	if err := c.cc.Invoke(ctx, "/plugin.Provisioner/Stop", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProvisionerServer is the server API for the Provisioner plugin service.
+type ProvisionerServer interface {
+	ValidateProvisionerConfig(context.Context, *ValidateProvisionerConfig_Request) (*ValidateProvisionerConfig_Response, error)
+	Apply(*ProvisionerApply_Request, Provisioner_ApplyServer) error
+	Stop(context.Context, *Stop_Request) (*Stop_Response, error)
+}
+
+func _Provisioner_ValidateProvisionerConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateProvisionerConfig_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProvisionerServer).ValidateProvisionerConfig(ctx, in)
+}
+
+func _Provisioner_Apply_Handler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(ProvisionerApply_Request)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(ProvisionerServer).Apply(in, &provisionerApplyServer{stream})
+}
+
+// Provisioner_ApplyServer is the server-side handle on the Apply response
+// stream: the implementation calls Send once per event it wants to deliver
+// before returning.
+type Provisioner_ApplyServer interface {
+	Send(*ProvisionerApply_Response) error
+	grpc.ServerStream
+}
+
+type provisionerApplyServer struct {
+	grpc.ServerStream
+}
+
+func (x *provisionerApplyServer) Send(m *ProvisionerApply_Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Provisioner_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Stop_Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	return srv.(ProvisionerServer).Stop(ctx, in)
+}
+
+// RegisterProvisionerServer registers srv with s so incoming RPCs are routed
+// to it.
+func RegisterProvisionerServer(s *grpc.Server, srv ProvisionerServer) {
+	s.RegisterService(&_Provisioner_serviceDesc, srv)
+}
+
+var _Provisioner_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "plugin.Provisioner",
+	HandlerType: (*ProvisionerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ValidateProvisionerConfig", Handler: _Provisioner_ValidateProvisionerConfig_Handler},
+		{MethodName: "Stop", Handler: _Provisioner_Stop_Handler},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Apply",
+			Handler:       _Provisioner_Apply_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "plugin.proto",
+}
@@ -0,0 +1,42 @@
+package proto
+
+type ValidateProvisionerConfig_Request struct {
+	Config *DynamicValue
+}
+
+type ValidateProvisionerConfig_Response struct {
+	Diagnostics []*Diagnostic
+}
+
+type ProvisionerApply_Request struct {
+	Config *DynamicValue
+}
+
+type ProvisionerApply_Response struct {
+	// Output carries a single line of plain-text progress output, for
+	// provisioners and callers that don't use the structured Event form.
+	Output string
+
+	// Event carries a structured progress event. A response has exactly one
+	// of Output or Event set.
+	Event *ProvisionerApply_Event
+}
+
+// ProvisionerApply_Event_Level is the severity of a ProvisionerApply_Event.
+type ProvisionerApply_Event_Level int32
+
+const (
+	ProvisionerApply_Event_INVALID ProvisionerApply_Event_Level = 0
+	ProvisionerApply_Event_INFO    ProvisionerApply_Event_Level = 1
+	ProvisionerApply_Event_WARN    ProvisionerApply_Event_Level = 2
+	ProvisionerApply_Event_ERROR   ProvisionerApply_Event_Level = 3
+)
+
+// ProvisionerApply_Event is a single structured event emitted while a
+// provisioner runs, as an alternative to plain output lines for callers that
+// want to tell log levels apart. It carries only a level and a message; it
+// has no progress field.
+type ProvisionerApply_Event struct {
+	Level   ProvisionerApply_Event_Level
+	Message string
+}
@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/hashicorp/go-plugin"
@@ -43,6 +44,15 @@ type ProvisionerFunc func() terraform.ResourceProvisioner
 type ServeOpts struct {
 	ProviderFunc    ProviderFunc
 	ProvisionerFunc ProvisionerFunc
+
+	// VersionedPlugins allows a plugin binary to additionally advertise
+	// older plugin sets, keyed by the core protocol version they implement.
+	// Serve passes this through to go-plugin's own VersionedPlugins
+	// support, which negotiates with the connecting client and serves
+	// whichever version they both support, preferring the newest. A
+	// provider that only supports the protocol version in Handshake does
+	// not need to set this.
+	VersionedPlugins map[int]plugin.PluginSet
 }
 
 // Serve serves a plugin. This function never returns and should be the final
@@ -51,12 +61,23 @@ func Serve(opts *ServeOpts) {
 	switch os.Getenv(TerraformPluginProtocol) {
 	case TerraformProtoGRPC:
 		plugin.Serve(&plugin.ServeConfig{
-			HandshakeConfig: Handshake,
-			Plugins:         pluginMap(opts),
-			GRPCServer:      plugin.DefaultGRPCServer,
+			HandshakeConfig:  Handshake,
+			Plugins:          pluginMap(opts),
+			VersionedPlugins: opts.VersionedPlugins,
+			GRPCServer:       plugin.DefaultGRPCServer,
 		})
 	default:
-		panic("protocol not supported")
+		// The client asked for a protocol we don't know how to speak at
+		// all (as opposed to one we know but no longer support, which
+		// VersionedPlugins negotiation handles above). Rather than
+		// panicking and leaving the client with an opaque plugin crash,
+		// report the mismatch the same way the client-side counterpart
+		// in NegotiateProtocolVersion does.
+		fmt.Fprintf(os.Stderr,
+			"This plugin only supports the %q protocol (set via the %s environment variable); the client requested %q.\n",
+			TerraformProtoGRPC, TerraformPluginProtocol, os.Getenv(TerraformPluginProtocol),
+		)
+		os.Exit(1)
 	}
 
 }
@@ -69,3 +90,34 @@ func pluginMap(opts *ServeOpts) map[string]plugin.Plugin {
 		"provisioner": &ResourceProvisionerPlugin{F: opts.ProvisionerFunc},
 	}
 }
+
+// NegotiateProtocolVersion is the client-side counterpart to the
+// VersionedPlugins support above: given the protocol versions core supports
+// (highest preference first) and the versions a launched plugin advertises,
+// it picks the highest version they have in common, the same selection
+// go-plugin performs internally during its handshake. It's exposed here so
+// that core's plugin client can degrade gracefully and explain itself: if
+// there's no overlap at all, the returned error lists both sides' supported
+// versions instead of leaving the operator with an opaque handshake failure
+// or a panic.
+func NegotiateProtocolVersion(coreVersions, pluginVersions []int) (int, error) {
+	supported := make(map[int]bool, len(pluginVersions))
+	for _, v := range pluginVersions {
+		supported[v] = true
+	}
+
+	best := 0
+	for _, v := range coreVersions {
+		if supported[v] && v > best {
+			best = v
+		}
+	}
+	if best == 0 {
+		return 0, fmt.Errorf(
+			"no plugin protocol version in common: Terraform supports %v, but this plugin only supports %v",
+			coreVersions, pluginVersions,
+		)
+	}
+
+	return best, nil
+}
@@ -0,0 +1,63 @@
+package terraform
+
+import "sync"
+
+// Context holds the state shared across a single plan, apply, refresh, or
+// validate operation: the configuration, state, and diff being operated on,
+// plus the pieces of caller-supplied configuration (hooks, input, provider
+// factories) that every module instance's evaluation needs access to.
+// ContextGraphWalker reads from it as it walks the graph built for the
+// operation.
+type Context struct {
+	hooks               []Hook
+	uiInput             UIInput
+	components          contextComponentFactory
+	providerInputConfig map[string]map[string]interface{}
+
+	diff     *Diff
+	diffLock sync.RWMutex
+
+	state     *State
+	stateLock sync.RWMutex
+
+	variables map[string]interface{}
+
+	// providerParallelism caps the number of resource nodes that may be
+	// calling into a given provider type at once, keyed by provider type
+	// name. ContextGraphWalker copies it into each BuiltinEvalContext, whose
+	// AcquireProviderSlot enforces it. Nothing in Context sets it from
+	// ApplyGraphBuilder.ProviderParallelism.
+	providerParallelism map[string]int
+
+	parallelSem Semaphore
+}
+
+// Semaphore is a counting semaphore used to cap how many graph nodes may be
+// evaluating concurrently.
+type Semaphore chan struct{}
+
+// NewSemaphore returns a Semaphore that allows up to n concurrent holders.
+func NewSemaphore(n int) Semaphore {
+	return make(Semaphore, n)
+}
+
+// Acquire blocks until a slot is available.
+func (s Semaphore) Acquire() {
+	s <- struct{}{}
+}
+
+// TryAcquire acquires a slot without blocking, returning false if none is
+// available.
+func (s Semaphore) TryAcquire() bool {
+	select {
+	case s <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release frees a previously-acquired slot.
+func (s Semaphore) Release() {
+	<-s
+}
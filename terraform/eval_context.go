@@ -23,6 +23,14 @@ type EvalContext interface {
 	// hook and should return the hook action to take and the error.
 	Hook(func(Hook) (HookAction, error)) error
 
+	// Emit pushes a structured, point-in-time event to the EvalTracer
+	// configured for this walk, automatically annotated with the current
+	// module path. It's the extension point eval nodes should use to expose
+	// progress (resource start/finish, provider configure, warnings) to
+	// external tools as a stable machine-readable stream, rather than
+	// requiring them to scrape the human-oriented Hook callbacks.
+	Emit(kind string, attrs map[string]interface{})
+
 	// Input is the UIInput object for interacting with the UI.
 	Input() UIInput
 
@@ -43,6 +51,15 @@ type EvalContext interface {
 	// CloseProvider closes provider connections that aren't needed anymore.
 	CloseProvider(string) error
 
+	// AcquireProviderSlot blocks until a concurrency slot is available for
+	// the given provider configuration, returning a release function to call
+	// once the caller is done using the provider. The number of slots for a
+	// provider's type is controlled by ApplyGraphBuilder.ProviderParallelism;
+	// provider types with no configured limit acquire immediately. If the
+	// context is stopped while waiting, AcquireProviderSlot returns an error
+	// instead of blocking forever.
+	AcquireProviderSlot(addrs.AbsProviderConfig) (release func(), err error)
+
 	// ConfigureProvider configures the provider with the given
 	// configuration. This is a separate context call because this call
 	// is used to store the provider configuration for inheritance lookups
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/terraform/config/configschema"
@@ -44,9 +45,36 @@ type BuiltinEvalContext struct {
 	StateValue          *State
 	StateLock           *sync.RWMutex
 
+	// ProviderParallelism caps the number of concurrent callers that may
+	// hold a provider slot for a given provider type, keyed by type name
+	// (e.g. "aws"). Provider types absent from this map are unlimited. It
+	// is populated from ApplyGraphBuilder.ProviderParallelism and shared,
+	// read-only, across every module path's context.
+	ProviderParallelism map[string]int
+
+	// ProviderSlots holds the lazily-created semaphore channel backing
+	// AcquireProviderSlot for each provider type, shared across every
+	// module path's context the same way ProviderCache is. ProviderSlotLock
+	// guards lazy creation of entries.
+	ProviderSlots    map[string]chan struct{}
+	ProviderSlotLock *sync.Mutex
+
+	// Tracer, if set, receives structured events for provider and
+	// expression-evaluation activity performed through this context. If
+	// unset, events are silently discarded.
+	Tracer EvalTracer
+
 	once sync.Once
 }
 
+// tracer returns ctx.Tracer, or NullEvalTracer if none was configured.
+func (ctx *BuiltinEvalContext) tracer() EvalTracer {
+	if ctx.Tracer == nil {
+		return NullEvalTracer{}
+	}
+	return ctx.Tracer
+}
+
 // BuiltinEvalContext implements EvalContext
 var _ EvalContext = (*BuiltinEvalContext)(nil)
 
@@ -62,6 +90,10 @@ func (ctx *BuiltinEvalContext) Stopped() <-chan struct{} {
 func (ctx *BuiltinEvalContext) Hook(fn func(Hook) (HookAction, error)) error {
 	for _, h := range ctx.Hooks {
 		action, err := fn(h)
+		ctx.Emit("hook", map[string]interface{}{
+			"hook_type": fmt.Sprintf("%T", h),
+			"action":    int(action),
+		})
 		if err != nil {
 			return err
 		}
@@ -83,9 +115,24 @@ func (ctx *BuiltinEvalContext) Input() UIInput {
 	return ctx.InputValue
 }
 
+func (ctx *BuiltinEvalContext) Emit(kind string, attrs map[string]interface{}) {
+	merged := map[string]interface{}{
+		"module_path": ctx.Path().String(),
+	}
+	for k, v := range attrs {
+		merged[k] = v
+	}
+	ctx.tracer().RecordEvent(kind, merged)
+}
+
 func (ctx *BuiltinEvalContext) InitProvider(typeName, name string) (ResourceProvider, error) {
 	ctx.once.Do(ctx.init)
 
+	ctx.Emit("provider_init", map[string]interface{}{
+		"provider_type": typeName,
+		"provider_name": name,
+	})
+
 	// If we already initialized, it is an error
 	if p := ctx.Provider(name); p != nil {
 		return nil, fmt.Errorf("Provider '%s' already initialized", name)
@@ -103,26 +150,13 @@ func (ctx *BuiltinEvalContext) InitProvider(typeName, name string) (ResourceProv
 
 	ctx.ProviderCache[name] = p
 
-	// Also fetch and cache the provider's schema.
-	// FIXME: This is using a non-ideal provider API that requires us to
-	// request specific resource types, but we actually just want _all_ the
-	// resource types, so we'll list these first. Once the provider API is
-	// updated we'll get enough data to populate this whole structure in
-	// a single call.
-	resourceTypes := p.Resources()
-	dataSources := p.DataSources()
-	resourceTypeNames := make([]string, len(resourceTypes))
-	for i, t := range resourceTypes {
-		resourceTypeNames[i] = t.Name
-	}
-	dataSourceNames := make([]string, len(dataSources))
-	for i, t := range dataSources {
-		dataSourceNames[i] = t.Name
-	}
-	schema, err := p.GetSchema(&ProviderSchemaRequest{
-		DataSources:   dataSourceNames,
-		ResourceTypes: resourceTypeNames,
-	})
+	// Fetch and cache the provider's schema in a single round-trip. Older
+	// providers that don't understand ProviderSchemaRequest.All will just
+	// ignore it and return their full schema anyway, since that's already
+	// the cheapest thing for them to compute; providers that do understand
+	// it can use it to skip building the resource/data source name lists
+	// that we used to have to request explicitly.
+	schema, err := p.GetSchema(&ProviderSchemaRequest{All: true})
 	if err != nil {
 		return nil, fmt.Errorf("error fetching schema for %s: %s", name, err)
 	}
@@ -170,7 +204,43 @@ func (ctx *BuiltinEvalContext) CloseProvider(n string) error {
 	return nil
 }
 
+func (ctx *BuiltinEvalContext) AcquireProviderSlot(addr addrs.AbsProviderConfig) (func(), error) {
+	limit := ctx.ProviderParallelism[addr.ProviderConfig.Type]
+	if limit <= 0 {
+		// No configured limit for this provider type, so DAG-level
+		// parallelism is the only throttle.
+		return func() {}, nil
+	}
+
+	slot := ctx.providerSlot(addr.ProviderConfig.Type, limit)
+
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	case <-ctx.Stopped():
+		return nil, fmt.Errorf("interrupted while waiting for a %s provider slot", addr.ProviderConfig.Type)
+	}
+}
+
+// providerSlot returns the shared semaphore channel for the given provider
+// type, creating it with the given capacity the first time it's requested.
+func (ctx *BuiltinEvalContext) providerSlot(typeName string, limit int) chan struct{} {
+	ctx.ProviderSlotLock.Lock()
+	defer ctx.ProviderSlotLock.Unlock()
+
+	slot, ok := ctx.ProviderSlots[typeName]
+	if !ok {
+		slot = make(chan struct{}, limit)
+		ctx.ProviderSlots[typeName] = slot
+	}
+	return slot
+}
+
 func (ctx *BuiltinEvalContext) ConfigureProvider(n string, cfg cty.Value) tfdiags.Diagnostics {
+	ctx.Emit("provider_configure", map[string]interface{}{
+		"provider_name": n,
+	})
+
 	var diags tfdiags.Diagnostics
 	p := ctx.Provider(n)
 	if p == nil {
@@ -268,18 +338,29 @@ func (ctx *BuiltinEvalContext) CloseProvisioner(n string) error {
 }
 
 func (ctx *BuiltinEvalContext) EvaluateBlock(body hcl.Body, schema *configschema.Block, current *Resource) (cty.Value, hcl.Body, tfdiags.Diagnostics) {
+	start := time.Now()
+	span := ctx.tracer().BeginEval(nil, nil, ctx.PathValue)
+
 	var diags tfdiags.Diagnostics
 	scope := ctx.Evaluator.Scope(ctx.PathValue, current)
 	body, evalDiags := scope.ExpandBlock(body, schema)
 	diags = diags.Append(evalDiags)
 	val, evalDiags := scope.EvalBlock(body, schema)
 	diags = diags.Append(evalDiags)
+
+	ctx.tracer().EndEval(span, diags, time.Since(start))
 	return val, body, diags
 }
 
 func (ctx *BuiltinEvalContext) EvaluateExpr(expr hcl.Expression, wantType cty.Type, current *Resource) (cty.Value, tfdiags.Diagnostics) {
+	start := time.Now()
+	span := ctx.tracer().BeginEval(nil, nil, ctx.PathValue)
+
 	scope := ctx.Evaluator.Scope(ctx.PathValue, current)
-	return scope.EvalExpr(expr, wantType)
+	val, diags := scope.EvalExpr(expr, wantType)
+
+	ctx.tracer().EndEval(span, diags, time.Since(start))
+	return val, diags
 }
 
 func (ctx *BuiltinEvalContext) Path() addrs.ModuleInstance {
@@ -23,6 +23,9 @@ type MockEvalContext struct {
 	HookHook   Hook
 	HookError  error
 
+	EmitCalled bool
+	EmitEvents []MockEmittedEvent
+
 	InputCalled bool
 	InputInput  UIInput
 
@@ -43,6 +46,11 @@ type MockEvalContext struct {
 	CloseProviderName     string
 	CloseProviderProvider ResourceProvider
 
+	AcquireProviderSlotCalled  bool
+	AcquireProviderSlotAddr    addrs.AbsProviderConfig
+	AcquireProviderSlotRelease func()
+	AcquireProviderSlotError   error
+
 	ProviderInputCalled bool
 	ProviderInputAddr   addrs.ProviderConfig
 	ProviderInputValues map[string]cty.Value
@@ -112,6 +120,13 @@ type MockEvalContext struct {
 	StateLock   *sync.RWMutex
 }
 
+// MockEmittedEvent records a single call to MockEvalContext.Emit, for tests
+// that want to assert on the events an eval node pushed during its Eval.
+type MockEmittedEvent struct {
+	Kind  string
+	Attrs map[string]interface{}
+}
+
 // MockEvalContext implements EvalContext
 var _ EvalContext = (*MockEvalContext)(nil)
 
@@ -131,6 +146,11 @@ func (c *MockEvalContext) Hook(fn func(Hook) (HookAction, error)) error {
 	return c.HookError
 }
 
+func (c *MockEvalContext) Emit(kind string, attrs map[string]interface{}) {
+	c.EmitCalled = true
+	c.EmitEvents = append(c.EmitEvents, MockEmittedEvent{Kind: kind, Attrs: attrs})
+}
+
 func (c *MockEvalContext) Input() UIInput {
 	c.InputCalled = true
 	return c.InputInput
@@ -160,6 +180,15 @@ func (c *MockEvalContext) CloseProvider(n string) error {
 	return nil
 }
 
+func (c *MockEvalContext) AcquireProviderSlot(addr addrs.AbsProviderConfig) (func(), error) {
+	c.AcquireProviderSlotCalled = true
+	c.AcquireProviderSlotAddr = addr
+	if c.AcquireProviderSlotRelease == nil {
+		return func() {}, c.AcquireProviderSlotError
+	}
+	return c.AcquireProviderSlotRelease, c.AcquireProviderSlotError
+}
+
 func (c *MockEvalContext) ConfigureProvider(n string, cfg cty.Value) tfdiags.Diagnostics {
 	c.ConfigureProviderCalled = true
 	c.ConfigureProviderName = n
@@ -0,0 +1,22 @@
+package terraform
+
+// EvalDestroySlot is an EvalNode implementation that acquires a slot in
+// Sem for the duration of evaluating a single destroy-plan resource node,
+// then releases it, so DestroyPlanGraphBuilder.Parallelism actually caps
+// how many NodePlanDestroyableResource evaluations run at once rather than
+// existing only as a configuration value nothing reads. Every node shares
+// the one Sem, so the cap covers all destroy waves together.
+type EvalDestroySlot struct {
+	Sem Semaphore
+}
+
+func (n *EvalDestroySlot) Eval(ctx EvalContext) (interface{}, error) {
+	if n.Sem == nil {
+		return nil, nil
+	}
+
+	n.Sem.Acquire()
+	defer n.Sem.Release()
+
+	return nil, nil
+}
@@ -70,6 +70,41 @@ func (n *EvalCloseProvider) Eval(ctx EvalContext) (interface{}, error) {
 	return nil, nil
 }
 
+// ProviderVersioner is an optional extension of ResourceProvider that a
+// provider implementation can satisfy to report the specific version it
+// resolved to. A provider that doesn't implement it is left with an unknown
+// resolved version, the same as before anything called SetResolvedVersion.
+type ProviderVersioner interface {
+	ResourceProvider
+
+	// ProviderVersion reports the provider's resolved version, e.g.
+	// "2.3.1". known is false if the provider can't report one.
+	ProviderVersion() (version string, known bool)
+}
+
+// EvalSetProviderVersion is an EvalNode implementation that records an
+// already-initialized provider's resolved version against its graph node.
+// It runs during the graph walk, after ProviderVersionConstraintTransformer
+// has already run while the graph was built, so that transformer's
+// version check does not see a version recorded here.
+type EvalSetProviderVersion struct {
+	Name string
+	Node GraphNodeAttachProviderResolvedVersion
+}
+
+func (n *EvalSetProviderVersion) Eval(ctx EvalContext) (interface{}, error) {
+	versioner, ok := ctx.Provider(n.Name).(ProviderVersioner)
+	if !ok {
+		return nil, nil
+	}
+
+	if v, known := versioner.ProviderVersion(); known {
+		n.Node.SetResolvedVersion(v)
+	}
+
+	return nil, nil
+}
+
 // EvalGetProvider is an EvalNode implementation that retrieves an already
 // initialized provider instance for the given name.
 type EvalGetProvider struct {
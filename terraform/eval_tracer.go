@@ -0,0 +1,166 @@
+package terraform
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// EvalTracer is the interface implemented by anything that wants to observe
+// the evaluation of nodes in a Terraform graph walk. It is the natural
+// extension point for profiling which resources and providers dominate plan
+// and apply time, since BeginEval/EndEval bracket the work done by
+// ContextGraphWalker.EnterEvalTree/ExitEvalTree for a single vertex.
+// BuiltinEvalContext.EvaluateBlock and EvaluateExpr also call them around
+// each evaluation, passing a nil vertex and a nil node.
+//
+// Implementations must be safe for concurrent use, since a graph walk may
+// evaluate many vertices in parallel.
+type EvalTracer interface {
+	// BeginEval is called just before a vertex's eval tree is evaluated. The
+	// returned value, if non-nil, is passed back to the matching EndEval
+	// call so that implementations can correlate start and end without
+	// maintaining their own lookup table.
+	BeginEval(v dag.Vertex, n EvalNode, path addrs.ModuleInstance) interface{}
+
+	// EndEval is called once the vertex's eval tree has finished evaluating.
+	// span is whatever BeginEval returned for this vertex.
+	EndEval(span interface{}, diags tfdiags.Diagnostics, dur time.Duration)
+
+	// RecordEvent records a point-in-time occurrence that doesn't have a
+	// clear start/end, such as a provider being configured or a hook firing.
+	// attrs is a set of additional structured fields to attach to the event.
+	RecordEvent(kind string, attrs map[string]interface{})
+}
+
+// NullEvalTracer is an EvalTracer that discards everything. It is the
+// default tracer used when a Context is not configured with one.
+type NullEvalTracer struct{}
+
+var _ EvalTracer = NullEvalTracer{}
+
+func (NullEvalTracer) BeginEval(dag.Vertex, EvalNode, addrs.ModuleInstance) interface{} { return nil }
+func (NullEvalTracer) EndEval(interface{}, tfdiags.Diagnostics, time.Duration)          {}
+func (NullEvalTracer) RecordEvent(string, map[string]interface{})                       {}
+
+// jsonLinesSpan is the span value BeginEval returns for JSONLinesEvalTracer,
+// carrying just enough state to produce the matching end-of-span record.
+type jsonLinesSpan struct {
+	Vertex string    `json:"vertex"`
+	Path   string    `json:"path"`
+	Start  time.Time `json:"start"`
+}
+
+// JSONLinesEvalTracer is a default EvalTracer implementation that writes one
+// JSON object per line to W, describing each span and event as it occurs.
+// This is intended to be consumed by external tools (CI dashboards, TUI
+// wrappers) that want a stable machine-readable stream of evaluation
+// progress without having to understand the human-oriented Hook interface.
+type JSONLinesEvalTracer struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+var _ EvalTracer = (*JSONLinesEvalTracer)(nil)
+
+func (t *JSONLinesEvalTracer) BeginEval(v dag.Vertex, n EvalNode, path addrs.ModuleInstance) interface{} {
+	span := &jsonLinesSpan{
+		Vertex: dag.VertexName(v),
+		Path:   path.String(),
+		Start:  time.Now(),
+	}
+	t.writeLine(map[string]interface{}{
+		"type":   "begin",
+		"vertex": span.Vertex,
+		"path":   span.Path,
+	})
+	return span
+}
+
+func (t *JSONLinesEvalTracer) EndEval(spanVal interface{}, diags tfdiags.Diagnostics, dur time.Duration) {
+	span, ok := spanVal.(*jsonLinesSpan)
+	if !ok || span == nil {
+		return
+	}
+	t.writeLine(map[string]interface{}{
+		"type":        "end",
+		"vertex":      span.Vertex,
+		"path":        span.Path,
+		"duration_ms": dur.Seconds() * 1000,
+		"has_errors":  diags.HasErrors(),
+	})
+}
+
+func (t *JSONLinesEvalTracer) RecordEvent(kind string, attrs map[string]interface{}) {
+	line := map[string]interface{}{
+		"type": "event",
+		"kind": kind,
+	}
+	for k, v := range attrs {
+		line[k] = v
+	}
+	t.writeLine(line)
+}
+
+func (t *JSONLinesEvalTracer) writeLine(v map[string]interface{}) {
+	if t.W == nil {
+		return
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.W.Write(js)
+	t.W.Write([]byte("\n"))
+}
+
+// OTelSpanRecorder is the minimal surface JSONLinesEvalTracer's OpenTelemetry
+// counterpart needs from a tracing SDK: something that can start a span for
+// a name and later accept its completion. Terraform does not vendor an
+// OpenTelemetry SDK directly, so callers wire up their own implementation of
+// this interface backed by whatever otel exporter they've configured.
+type OTelSpanRecorder interface {
+	// StartSpan begins a span with the given name and attributes, returning
+	// an opaque handle to be passed to EndSpan.
+	StartSpan(name string, attrs map[string]interface{}) interface{}
+
+	// EndSpan completes the span represented by handle.
+	EndSpan(handle interface{}, err error)
+
+	// AddEvent records a named event with no associated span, such as a
+	// provider configure call.
+	AddEvent(name string, attrs map[string]interface{})
+}
+
+// OTelEvalTracer adapts an OTelSpanRecorder to the EvalTracer interface, so
+// that a graph walk can be profiled with whatever OpenTelemetry-compatible
+// tracing backend the caller has configured.
+type OTelEvalTracer struct {
+	Recorder OTelSpanRecorder
+}
+
+var _ EvalTracer = (*OTelEvalTracer)(nil)
+
+func (t *OTelEvalTracer) BeginEval(v dag.Vertex, n EvalNode, path addrs.ModuleInstance) interface{} {
+	return t.Recorder.StartSpan(dag.VertexName(v), map[string]interface{}{
+		"module_path": path.String(),
+	})
+}
+
+func (t *OTelEvalTracer) EndEval(span interface{}, diags tfdiags.Diagnostics, dur time.Duration) {
+	t.Recorder.EndSpan(span, diags.Err())
+}
+
+func (t *OTelEvalTracer) RecordEvent(kind string, attrs map[string]interface{}) {
+	t.Recorder.AddEvent(kind, attrs)
+}
@@ -71,21 +71,7 @@ func (n *EvalValidateProvider) Eval(ctx EvalContext) (interface{}, error) {
 	provider := *n.Provider
 	config := *n.Config
 
-	warns, errs := provider.Validate(config)
-	if len(warns) == 0 && len(errs) == 0 {
-		return nil, nil
-	}
-
-	// FIXME: Once provider.Validate itself returns diagnostics, just
-	// return diags.NonFatalErr() immediately here.
-	var diags tfdiags.Diagnostics
-	for _, warn := range warns {
-		diags = diags.Append(tfdiags.SimpleWarning(warn))
-	}
-	for _, err := range errs {
-		diags = diags.Append(err)
-	}
-
+	diags := provider.Validate(config)
 	return nil, diags.NonFatalErr()
 }
 
@@ -211,8 +197,7 @@ func (n *EvalValidateResource) Eval(ctx EvalContext) (interface{}, error) {
 	cfg := *n.Config
 	mode := cfg.Mode
 
-	var warns []string
-	var errs []error
+	var providerDiags tfdiags.Diagnostics
 
 	// Provider entry point varies depending on resource mode, because
 	// managed resources and data resources are two distinct concepts
@@ -239,7 +224,7 @@ func (n *EvalValidateResource) Eval(ctx EvalContext) (interface{}, error) {
 		// The provider API still expects our legacy types, so we must do some
 		// shimming here.
 		legacyCfg := NewResourceConfigShimmed(configVal, schema)
-		warns, errs = provider.ValidateResource(cfg.Type, legacyCfg)
+		providerDiags = provider.ValidateResource(cfg.Type, legacyCfg)
 
 	case addrs.DataResourceMode:
 		schema, exists := n.ProviderSchema.DataSources[cfg.Type]
@@ -262,18 +247,10 @@ func (n *EvalValidateResource) Eval(ctx EvalContext) (interface{}, error) {
 		// The provider API still expects our legacy types, so we must do some
 		// shimming here.
 		legacyCfg := NewResourceConfigShimmed(configVal, schema)
-		warns, errs = provider.ValidateDataSource(cfg.Type, legacyCfg)
+		providerDiags = provider.ValidateDataSource(cfg.Type, legacyCfg)
 	}
 
-	// FIXME: Update the provider API to actually return diagnostics here,
-	// and then we can remove all this shimming and use its diagnostics
-	// directly.
-	for _, warn := range warns {
-		diags = diags.Append(tfdiags.SimpleWarning(warn))
-	}
-	for _, err := range errs {
-		diags = diags.Append(err)
-	}
+	diags = diags.Append(providerDiags)
 
 	if n.IgnoreWarnings {
 		// If we _only_ have warnings then we'll return nil.
@@ -44,6 +44,24 @@ type ApplyGraphBuilder struct {
 
 	// Validate will do structural validation of the graph.
 	Validate bool
+
+	// ProviderParallelism caps the number of resource nodes that may be
+	// calling into a given provider type at once, keyed by provider type
+	// name (e.g. "aws"). Provider types absent from this map are bound only
+	// by the overall DAG-level parallelism. The limits are enforced by
+	// EvalContext.AcquireProviderSlot, for those callers that acquire a slot.
+	// Steps does not read this field, and nothing here copies it into a
+	// Context or makes the resource nodes it builds acquire a slot.
+	ProviderParallelism map[string]int
+
+	// Eval, if set, is used to evaluate "for_each" expressions on provider
+	// configurations that expand into multiple aliases. See
+	// ProviderAliasExpansionTransformer for details.
+	Eval EvalContext
+
+	// ProviderResolver, if set, overrides how resources without an explicit
+	// provider configuration are resolved. See AttachProviderResolverTransformer.
+	ProviderResolver ProviderResolver
 }
 
 // See GraphBuilder
@@ -86,8 +104,12 @@ func (b *ApplyGraphBuilder) Steps() []GraphTransformer {
 		// Attach the state
 		&AttachStateTransformer{State: b.State},
 
+		// Let a custom resolution strategy override implicit provider
+		// resolution, if one was supplied.
+		&AttachProviderResolverTransformer{Resolver: b.ProviderResolver},
+
 		// add providers
-		TransformProviders(b.Providers, concreteProvider, b.Config),
+		TransformProviders(b.Providers, concreteProvider, b.Config, b.Eval),
 
 		// Destruction ordering
 		&DestroyEdgeTransformer{Config: b.Config, State: b.State},
@@ -118,6 +140,16 @@ func (b *ApplyGraphBuilder) Steps() []GraphTransformer {
 		// Connect references so ordering is correct
 		&ReferenceTransformer{},
 
+		// Invert the edges for any destroy-time provisioner references
+		// ReferenceTransformer didn't account for, so a destroy-time
+		// provisioner's dependencies are still present when it runs.
+		&DestroyReferenceTransformer{},
+
+		// Order each resource after the resources named by its
+		// StateDependencies, which for a resource whose config is gone come
+		// from the dependencies recorded in state.
+		&StateDependencyTransformer{},
+
 		// Handle destroy time transformations for output and local values.
 		// Reverse the edges from outputs and locals, so that
 		// interpolations don't fail during destroy.
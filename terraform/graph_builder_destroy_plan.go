@@ -22,6 +22,16 @@ type DestroyPlanGraphBuilder struct {
 	// Targets are resources to target
 	Targets []string
 
+	// Parallelism caps the number of NodePlanDestroyableResource evaluations
+	// that may run concurrently. One semaphore of this size is shared by
+	// every such node whatever its destroy wave (see DestroyWaveTransformer),
+	// so the cap applies to all waves together, not to each wave separately.
+	// Waves are not separated by a barrier: a vertex starts once its own
+	// dependencies have finished, so vertices of different waves can run at
+	// the same time. A zero value means no additional cap beyond the
+	// walker's overall parallelism.
+	Parallelism int
+
 	// Validate will do structural validation of the graph.
 	Validate bool
 }
@@ -37,9 +47,15 @@ func (b *DestroyPlanGraphBuilder) Build(path addrs.ModuleInstance) (*Graph, tfdi
 
 // See GraphBuilder
 func (b *DestroyPlanGraphBuilder) Steps() []GraphTransformer {
+	var sem Semaphore
+	if b.Parallelism > 0 {
+		sem = NewSemaphore(b.Parallelism)
+	}
+
 	concreteResource := func(a *NodeAbstractResource) dag.Vertex {
 		return &NodePlanDestroyableResource{
 			NodeAbstractResource: a,
+			Sem:                  sem,
 		}
 	}
 
@@ -57,10 +73,20 @@ func (b *DestroyPlanGraphBuilder) Steps() []GraphTransformer {
 		// targeting below will prune the correct things.
 		&DestroyEdgeTransformer{Config: b.Config, State: b.State},
 
+		// Number each destroy-plan node with the wave of mutually
+		// independent nodes it belongs to. This only records the wave on
+		// each node; it adds and removes no edges.
+		&DestroyWaveTransformer{},
+
 		// Target. Note we don't set "Destroy: true" here since we already
 		// created proper destroy ordering.
 		&TargetsTransformer{Targets: b.Targets},
 
+		// Targeting can prune an entire wave; re-run the wave partitioning
+		// so the surviving nodes are renumbered into a dense 0..N sequence
+		// instead of leaving gaps that would confuse progress reporting.
+		&DestroyWaveTransformer{},
+
 		// Single root
 		&RootTransformer{},
 	}
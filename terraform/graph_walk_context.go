@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform/tfdiags"
 
@@ -22,6 +23,10 @@ type ContextGraphWalker struct {
 	Operation   walkOperation
 	StopContext context.Context
 
+	// Tracer, if set, receives structured spans and events for each vertex
+	// evaluated during the walk. If unset, NullEvalTracer is used.
+	Tracer EvalTracer
+
 	// This is an output. Do not set this, nor read it while a graph walk
 	// is in progress.
 	NonFatalDiagnostics tfdiags.Diagnostics
@@ -36,6 +41,36 @@ type ContextGraphWalker struct {
 	providerLock        sync.Mutex
 	provisionerCache    map[string]ResourceProvisioner
 	provisionerLock     sync.Mutex
+	providerSlots       map[string]chan struct{}
+	providerSlotLock    sync.Mutex
+
+	spans    map[dag.Vertex]spanRecord
+	spanLock sync.Mutex
+}
+
+// spanRecord tracks the in-flight tracer span for a vertex between
+// EnterEvalTree and ExitEvalTree.
+type spanRecord struct {
+	span  interface{}
+	start time.Time
+}
+
+// tracer returns the configured EvalTracer, or NullEvalTracer if none was
+// set.
+func (w *ContextGraphWalker) tracer() EvalTracer {
+	if w.Tracer == nil {
+		return NullEvalTracer{}
+	}
+	return w.Tracer
+}
+
+// vertexPath returns the module path a vertex belongs to, if it exposes one,
+// or the root module instance otherwise.
+func (w *ContextGraphWalker) vertexPath(v dag.Vertex) addrs.ModuleInstance {
+	if sp, ok := v.(GraphNodeSubPath); ok {
+		return sp.Path()
+	}
+	return addrs.RootModuleInstance
 }
 
 func (w *ContextGraphWalker) EnterPath(path addrs.ModuleInstance) EvalContext {
@@ -89,6 +124,10 @@ func (w *ContextGraphWalker) EnterPath(path addrs.ModuleInstance) EvalContext {
 		StateValue:          w.Context.state,
 		StateLock:           &w.Context.stateLock,
 		Evaluator:           evaluator,
+		Tracer:              w.tracer(),
+		ProviderParallelism: w.Context.providerParallelism,
+		ProviderSlots:       w.providerSlots,
+		ProviderSlotLock:    &w.providerSlotLock,
 	}
 
 	w.contexts[key] = ctx
@@ -102,6 +141,16 @@ func (w *ContextGraphWalker) EnterEvalTree(v dag.Vertex, n EvalNode) EvalNode {
 	// Acquire a lock on the semaphore
 	w.Context.parallelSem.Acquire()
 
+	w.spanLock.Lock()
+	if w.spans == nil {
+		w.spans = make(map[dag.Vertex]spanRecord)
+	}
+	w.spans[v] = spanRecord{
+		span:  w.tracer().BeginEval(v, n, w.vertexPath(v)),
+		start: time.Now(),
+	}
+	w.spanLock.Unlock()
+
 	// We want to filter the evaluation tree to only include operations
 	// that belong in this operation.
 	return EvalFilter(n, EvalNodeFilterOp(w.Operation))
@@ -114,6 +163,22 @@ func (w *ContextGraphWalker) ExitEvalTree(v dag.Vertex, output interface{}, err
 	// Release the semaphore
 	w.Context.parallelSem.Release()
 
+	w.spanLock.Lock()
+	rec, ok := w.spans[v]
+	if ok {
+		delete(w.spans, v)
+	}
+	w.spanLock.Unlock()
+	if ok {
+		var diags tfdiags.Diagnostics
+		if nferr, ok := err.(tfdiags.NonFatalError); ok {
+			diags = nferr.Diagnostics
+		} else if err != nil {
+			diags = diags.Append(err)
+		}
+		w.tracer().EndEval(rec.span, diags, time.Since(rec.start))
+	}
+
 	if err == nil {
 		return nil
 	}
@@ -144,4 +209,5 @@ func (w *ContextGraphWalker) init() {
 	w.providerCache = make(map[string]ResourceProvider, 5)
 	w.provisionerCache = make(map[string]ResourceProvisioner, 5)
 	w.interpolaterVars = make(map[string]map[string]interface{}, 5)
+	w.providerSlots = make(map[string]chan struct{})
 }
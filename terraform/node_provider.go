@@ -0,0 +1,113 @@
+package terraform
+
+import (
+	"github.com/hashicorp/go-version"
+
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// NodeAbstractProvider represents a provider configuration that has no
+// associated operation, analogous to NodeAbstractResource for resources. It
+// exists so that concrete, operation-specific provider node types (so far
+// just NodeApplyableProvider) can share the same identity, path, and
+// resolved-version plumbing instead of each reimplementing it.
+type NodeAbstractProvider struct {
+	Addr addrs.AbsProviderConfig
+
+	// ProviderVersionConstraint, set via AttachProviderVersionConstraint
+	// (normally by ProviderVersionConstraintTransformer), is the combined
+	// required_providers constraint resolved for Addr across every module
+	// that references it.
+	ProviderVersionConstraint version.Constraints
+
+	// ResolvedVersion is the specific provider version this configuration
+	// has resolved to, e.g. once a plugin has been selected for it. It's set
+	// via SetResolvedVersion, called from EvalSetProviderVersion once the
+	// provider has been initialized, for any provider that reports its
+	// version by implementing ProviderVersioner; until then, or for a
+	// provider that doesn't implement it, ProviderVersion reports it as
+	// unknown rather than firing ProviderVersionConstraintTransformer's
+	// version-mismatch diagnostic against a version we never actually
+	// resolved.
+	ResolvedVersion string
+}
+
+// GraphNodeAttachProviderResolvedVersion is implemented by nodes that can
+// record a provider's resolved version once it's known, e.g. once the
+// provider has been initialized and asked for one via ProviderVersioner.
+type GraphNodeAttachProviderResolvedVersion interface {
+	SetResolvedVersion(version string)
+}
+
+var (
+	_ GraphNodeSubPath                       = (*NodeAbstractProvider)(nil)
+	_ GraphNodeProvider                      = (*NodeAbstractProvider)(nil)
+	_ GraphNodeAttachProviderVersion         = (*NodeAbstractProvider)(nil)
+	_ GraphNodeProviderVersion               = (*NodeAbstractProvider)(nil)
+	_ GraphNodeAttachProviderResolvedVersion = (*NodeAbstractProvider)(nil)
+)
+
+func (n *NodeAbstractProvider) Name() string {
+	return n.Addr.String()
+}
+
+// GraphNodeSubPath
+func (n *NodeAbstractProvider) Path() addrs.ModuleInstance {
+	return n.Addr.Module
+}
+
+// GraphNodeProvider
+func (n *NodeAbstractProvider) ProviderAddr() addrs.AbsProviderConfig {
+	return n.Addr
+}
+
+// GraphNodeAttachProviderVersion
+func (n *NodeAbstractProvider) AttachProviderVersionConstraint(c version.Constraints) {
+	n.ProviderVersionConstraint = c
+}
+
+// SetResolvedVersion records the specific provider version this
+// configuration has resolved to, so GraphNodeProviderVersion can report it
+// back to ProviderVersionConstraintTransformer.
+func (n *NodeAbstractProvider) SetResolvedVersion(v string) {
+	n.ResolvedVersion = v
+}
+
+// GraphNodeProviderVersion
+func (n *NodeAbstractProvider) ProviderVersion() (ver string, known bool) {
+	return n.ResolvedVersion, n.ResolvedVersion != ""
+}
+
+// NodeApplyableProvider represents a provider configuration during apply.
+// ApplyGraphBuilder wraps every provider node in this type via its Concrete
+// factory (see graph_builder_apply.go); it exists as its own type, distinct
+// from NodeAbstractProvider, purely so later apply-specific behavior has
+// somewhere to attach without affecting the plan or destroy-plan graph
+// builders, which never reference NodeApplyableProvider.
+type NodeApplyableProvider struct {
+	*NodeAbstractProvider
+}
+
+var (
+	_ GraphNodeSubPath               = (*NodeApplyableProvider)(nil)
+	_ GraphNodeProvider              = (*NodeApplyableProvider)(nil)
+	_ GraphNodeAttachProviderVersion = (*NodeApplyableProvider)(nil)
+	_ GraphNodeProviderVersion       = (*NodeApplyableProvider)(nil)
+	_ GraphNodeEvalable              = (*NodeApplyableProvider)(nil)
+)
+
+// GraphNodeEvalable
+func (n *NodeApplyableProvider) EvalTree() EvalNode {
+	return &EvalSequence{
+		Nodes: []EvalNode{
+			&EvalInitProvider{
+				TypeName: n.Addr.ProviderConfig.Type,
+				Name:     n.Addr.String(),
+			},
+			&EvalSetProviderVersion{
+				Name: n.Addr.String(),
+				Node: n.NodeAbstractProvider,
+			},
+		},
+	}
+}
@@ -2,7 +2,6 @@ package terraform
 
 import (
 	"log"
-	"strings"
 
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/hcl2/hcl/hclsyntax"
@@ -27,6 +26,17 @@ type GraphNodeResource interface {
 	ResourceAddr() addrs.AbsResource
 }
 
+// GraphNodeDestroyReferencer is implemented by nodes whose destroy-time
+// provisioners (those with when = destroy) reference other resources, such
+// as through their connection or config blocks. Unlike GraphNodeReferencer,
+// these references have their edges inverted, by DestroyReferenceTransformer:
+// a destroy-time provisioner needs the resources it refers to to still
+// exist when it runs, which is the opposite of the usual "depend on what
+// you reference" ordering.
+type GraphNodeDestroyReferencer interface {
+	DestroyReferences() []*addrs.Reference
+}
+
 // NodeAbstractResource represents a resource that has no associated
 // operations. It registers all the interfaces for a resource that common
 // across multiple operation types.
@@ -45,18 +55,28 @@ type NodeAbstractResource struct {
 
 	// The address of the provider this resource will use
 	ResolvedProvider addrs.AbsProviderConfig
+
+	// Resolver, if set via SetProviderResolver (typically by
+	// AttachProviderResolverTransformer), is consulted by ProvidedBy in
+	// place of the default Guess behavior when neither Config nor
+	// ResourceState specifies a provider configuration.
+	Resolver ProviderResolver
 }
 
 var (
-	_ GraphNodeSubPath              = (*NodeAbstractResource)(nil)
-	_ GraphNodeReferenceable        = (*NodeAbstractResource)(nil)
-	_ GraphNodeReferencer           = (*NodeAbstractResource)(nil)
-	_ GraphNodeProviderConsumer     = (*NodeAbstractResource)(nil)
-	_ GraphNodeProvisionerConsumer  = (*NodeAbstractResource)(nil)
-	_ GraphNodeResource             = (*NodeAbstractResource)(nil)
-	_ GraphNodeAttachResourceState  = (*NodeAbstractResource)(nil)
-	_ GraphNodeAttachResourceConfig = (*NodeAbstractResource)(nil)
-	_ GraphNodeDotter               = (*NodeAbstractResource)(nil)
+	_ GraphNodeSubPath                  = (*NodeAbstractResource)(nil)
+	_ GraphNodeReferenceable            = (*NodeAbstractResource)(nil)
+	_ GraphNodeReferencer               = (*NodeAbstractResource)(nil)
+	_ GraphNodeProviderConsumer         = (*NodeAbstractResource)(nil)
+	_ GraphNodeProvisionerConsumer      = (*NodeAbstractResource)(nil)
+	_ GraphNodeResource                 = (*NodeAbstractResource)(nil)
+	_ GraphNodeDestroyReferencer        = (*NodeAbstractResource)(nil)
+	_ GraphNodeProviderInstanceConsumer = (*NodeAbstractResource)(nil)
+	_ GraphNodeAttachProviderResolver   = (*NodeAbstractResource)(nil)
+	_ GraphNodeProviderConsumerSubject  = (*NodeAbstractResource)(nil)
+	_ GraphNodeAttachResourceState      = (*NodeAbstractResource)(nil)
+	_ GraphNodeAttachResourceConfig     = (*NodeAbstractResource)(nil)
+	_ GraphNodeDotter                   = (*NodeAbstractResource)(nil)
 )
 
 func (n *NodeAbstractResource) Name() string {
@@ -138,71 +158,105 @@ func (n *NodeAbstractResource) References() []*addrs.Reference {
 	return nil
 }
 
-// StateReferences returns the dependencies to put into the state for
-// this resource.
-func (n *NodeAbstractResource) StateReferences() []string {
-	self := n.ReferenceableName()
-
-	// Determine what our "prefix" is for checking for references to
-	// ourself.
-	addrCopy := n.Addr.Copy()
-	addrCopy.Index = -1
-	selfPrefix := addrCopy.String() + "."
-
-	depsRaw := n.References()
-	deps := make([]string, 0, len(depsRaw))
-	for _, d := range depsRaw {
-		// Ignore any variable dependencies
-		if strings.HasPrefix(d, "var.") {
-			continue
-		}
+// DestroyReferences returns the references made by this resource's
+// destroy-time (when = destroy) provisioners' connection and config blocks.
+// These are kept separate from References because DestroyReferenceTransformer
+// inverts their edges: a destroy-time provisioner depends on the resources it
+// references still being present, rather than the other way around.
+func (n *NodeAbstractResource) DestroyReferences() []*addrs.Reference {
+	c := n.Config
+	if c == nil || c.Managed == nil {
+		return nil
+	}
 
-		// If this has a backup ref, ignore those for now. The old state
-		// file never contained those and I'd rather store the rich types we
-		// add in the future.
-		if idx := strings.IndexRune(d, '/'); idx != -1 {
-			d = d[:idx]
+	var result []*addrs.Reference
+	for _, p := range c.Managed.Provisioners {
+		if p.When != configs.ProvisionerWhenDestroy {
+			continue
 		}
+		refs, _ := lang.ReferencesInBlock(p.Connection, connectionSchema) // TODO: define connectionSchema
+		result = append(result, refs...)
+		refs, _ = lang.ReferencesInBlock(p.Config, provisionerSchema) // TODO: How do we get this schema in here?
+		result = append(result, refs...)
+	}
+	return result
+}
 
-		// If we're referencing ourself, then ignore it
-		found := false
-		for _, s := range self {
-			if d == s {
-				found = true
-			}
-		}
-		if found {
+// StateDependencies returns the set of other resources this resource
+// depends on, as structured addresses to be recorded in the state. This
+// replaces the old practice of flattening references down to strings like
+// "module.foo.bar", which lost the resource's type and any specific
+// instance index and so could only support approximate, string-matching
+// dependency tracking. Round-tripping through addrs.ConfigResource instead
+// lets targeted destroys and refresh treat recorded dependencies exactly.
+func (n *NodeAbstractResource) StateDependencies() []addrs.ConfigResource {
+	selfAddr := n.Addr.Resource.InModule(n.Addr.Module.Module())
+
+	var result []addrs.ConfigResource
+	seen := make(map[string]bool)
+	for _, ref := range n.References() {
+		var resAddr addrs.Resource
+		switch s := ref.Subject.(type) {
+		case addrs.Resource:
+			resAddr = s
+		case addrs.ResourceInstance:
+			resAddr = s.Resource
+		default:
+			// Not a reference to a resource at all, e.g. a variable or
+			// local value; those aren't recorded as state dependencies.
 			continue
 		}
 
-		// If this is a reference to ourself and a specific index, we keep
-		// it. For example, if this resource is "foo.bar" and the reference
-		// is "foo.bar.0" then we keep it exact. Otherwise, we strip it.
-		if strings.HasSuffix(d, ".0") && !strings.HasPrefix(d, selfPrefix) {
-			d = d[:len(d)-2]
+		configAddr := resAddr.InModule(n.Addr.Module.Module())
+		if configAddr.String() == selfAddr.String() {
+			// Don't record a dependency on ourself.
+			continue
 		}
 
-		// This is sad. The dependencies are currently in the format of
-		// "module.foo.bar" (the full field). This strips the field off.
-		if strings.HasPrefix(d, "module.") {
-			parts := strings.SplitN(d, ".", 3)
-			d = strings.Join(parts[0:2], ".")
+		key := configAddr.String()
+		if seen[key] {
+			continue
 		}
+		seen[key] = true
 
-		deps = append(deps, d)
+		result = append(result, configAddr)
 	}
 
-	return deps
+	return result
 }
 
 func (n *NodeAbstractResource) SetProvider(p addrs.AbsProviderConfig) {
 	n.ResolvedProvider = p
 }
 
+// GraphNodeProviderConsumerSubject
+func (n *NodeAbstractResource) ProviderConsumerSubjectRange() (hcl.Range, bool) {
+	if n.Config == nil {
+		return hcl.Range{}, false
+	}
+	return n.Config.DeclRange, true
+}
+
+// GraphNodeAttachProviderResolver
+func (n *NodeAbstractResource) SetProviderResolver(r ProviderResolver) {
+	n.Resolver = r
+}
+
 // GraphNodeProviderConsumer
 func (n *NodeAbstractResource) ProvidedBy() (addrs.AbsProviderConfig, bool) {
 	// If we have a config we prefer that above all else
 	if n.Config != nil {
+		// A "provider = aws[each.key]" reference can't be resolved here: which
+		// alias it names depends on evaluating each.key, which needs more
+		// context than this node has on its own. Defer to the attached
+		// resolver, the same extension point used to resolve a reference this
+		// node has no better answer for at all.
+		if ref := n.Config.ProviderConfigRef; ref != nil && ref.KeyExpression != nil && n.Resolver != nil {
+			if addr, ok := n.Resolver.ResolveProvider(n); ok {
+				return addr, false
+			}
+		}
+
 		relAddr := n.Config.ProviderConfigAddr()
 		return relAddr.Absolute(n.Path()), false
 	}
@@ -221,6 +275,15 @@ func (n *NodeAbstractResource) ProvidedBy() (addrs.AbsProviderConfig, bool) {
 			goto Guess
 		}
 
+		// State predating source addresses never recorded a Namespace or
+		// Hostname, so migrate it to the implied default source here rather
+		// than leaving it looking like a provider with no source at all;
+		// providerConfigKey normalizes the same way when matching this
+		// address against the graph's provider nodes, so this doesn't
+		// change which provider configuration a pre-existing resource
+		// resolves to.
+		addr.ProviderConfig = addr.ProviderConfig.LegacySource()
+
 		// An address from the state must match exactly, since we must ensure
 		// we refresh/destroy a resource with the same provider configuration
 		// that created it.
@@ -228,10 +291,34 @@ func (n *NodeAbstractResource) ProvidedBy() (addrs.AbsProviderConfig, bool) {
 	}
 
 Guess:
+	// If a resolver has been attached, give it a chance to do better than
+	// guessing, e.g. by inheriting an alias from a parent module or
+	// consulting a required_providers block.
+	if n.Resolver != nil {
+		if addr, ok := n.Resolver.ResolveProvider(n); ok {
+			return addr, false
+		}
+	}
+
 	// Use our type and containing module path to guess a provider configuration address
 	return addrs.NewDefaultProviderConfig(n.Addr.Resource.Type).Absolute(n.Addr.Module), false
 }
 
+// GraphNodeProviderInstanceConsumer
+//
+// ProviderInstanceKey reports the "provider_instance" meta-argument from the
+// resource block, if one was set, so ProviderInstanceTransformer can pin this
+// resource to a pooled instance of its provider instead of the module's
+// single shared one. It only has a config-derived answer: state alone (the
+// ProvidedBy fallback above) carries no record of which instance a resource
+// was previously pinned to.
+func (n *NodeAbstractResource) ProviderInstanceKey() (string, bool) {
+	if n.Config == nil || n.Config.ProviderInstanceKey == "" {
+		return "", false
+	}
+	return n.Config.ProviderInstanceKey, true
+}
+
 // GraphNodeProvisionerConsumer
 func (n *NodeAbstractResource) ProvisionedBy() []string {
 	// If we have no configuration, then we have no provisioners
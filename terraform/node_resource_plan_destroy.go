@@ -0,0 +1,43 @@
+package terraform
+
+// NodePlanDestroyableResource represents a resource during a pure-destroy
+// plan. DestroyPlanGraphBuilder wraps every resource node in this type via
+// its Concrete factory (see graph_builder_destroy_plan.go); it exists as
+// its own type, distinct from NodeAbstractResource, so destroy-plan-only
+// behavior has somewhere to attach without affecting the apply or normal
+// plan graph builders, which never reference NodePlanDestroyableResource.
+type NodePlanDestroyableResource struct {
+	*NodeAbstractResource
+
+	// DestroyWave is the wave this node was assigned to by
+	// DestroyWaveTransformer; see GraphNodeAttachDestroyWave.
+	DestroyWave int
+
+	// Sem, if non-nil, caps the number of NodePlanDestroyableResource
+	// evaluations that may run concurrently, across all waves together. It's
+	// built once from DestroyPlanGraphBuilder.Parallelism and shared
+	// across every node the builder's Concrete factory constructs, much as
+	// BuiltinEvalContext.ProviderParallelism is shared by the callers of
+	// AcquireProviderSlot.
+	Sem Semaphore
+}
+
+var (
+	_ GraphNodeResource          = (*NodePlanDestroyableResource)(nil)
+	_ GraphNodeAttachDestroyWave = (*NodePlanDestroyableResource)(nil)
+	_ GraphNodeEvalable          = (*NodePlanDestroyableResource)(nil)
+)
+
+// GraphNodeAttachDestroyWave
+func (n *NodePlanDestroyableResource) SetDestroyWave(wave int) {
+	n.DestroyWave = wave
+}
+
+// GraphNodeEvalable
+func (n *NodePlanDestroyableResource) EvalTree() EvalNode {
+	return &EvalSequence{
+		Nodes: []EvalNode{
+			&EvalDestroySlot{Sem: n.Sem},
+		},
+	}
+}
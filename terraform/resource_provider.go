@@ -0,0 +1,83 @@
+package terraform
+
+import "github.com/hashicorp/terraform/tfdiags"
+
+// ResourceProvider is the interface that must be implemented by a resource
+// provider: the thing that creates, reads, updates, and destroys resources
+// of one or more types, and answers for one or more data sources. Providers
+// shipped as separate plugin binaries are accessed through
+// plugin.GRPCResourceProvider, which implements this interface by making
+// RPCs to the plugin process.
+type ResourceProvider interface {
+	// GetSchema returns the schema for the provider itself, every resource
+	// type it implements, and every data source it implements. req exists
+	// so that callers can document which of those they actually need, but
+	// every known implementation returns the full schema regardless.
+	GetSchema(req *ProviderSchemaRequest) (*ProviderSchema, error)
+
+	// Input asks for any values the given configuration is missing,
+	// prompting interactively via input, and returns the configuration with
+	// those values merged in.
+	Input(input UIInput, c *ResourceConfig) (*ResourceConfig, error)
+
+	// Validate checks the provider's own configuration for correctness,
+	// independent of any particular resource or data source.
+	Validate(c *ResourceConfig) tfdiags.Diagnostics
+
+	// ValidateResource checks a resource type's configuration for
+	// correctness ahead of Diff/Apply.
+	ValidateResource(t string, c *ResourceConfig) tfdiags.Diagnostics
+
+	// ValidateDataSource checks a data source's configuration for
+	// correctness ahead of ReadDataDiff/ReadDataApply.
+	ValidateDataSource(t string, c *ResourceConfig) tfdiags.Diagnostics
+
+	// Configure configures the provider itself with the given configuration,
+	// ahead of any resource or data source operation.
+	Configure(c *ResourceConfig) error
+
+	// Refresh reads the real, current state of a resource instance.
+	Refresh(info *InstanceInfo, s *InstanceState) (*InstanceState, error)
+
+	// Diff computes the changes that applying c to the resource instance
+	// currently in state s would make.
+	Diff(info *InstanceInfo, s *InstanceState, c *ResourceConfig) (*InstanceDiff, error)
+
+	// Apply makes the changes described by d to the resource instance
+	// currently in state s, returning its new state.
+	Apply(info *InstanceInfo, s *InstanceState, d *InstanceDiff) (*InstanceState, error)
+
+	// ImportState imports one or more resource instances identified by id,
+	// returning the state of each.
+	ImportState(info *InstanceInfo, id string) ([]*InstanceState, error)
+
+	// UpgradeState migrates rawJSON, a resource instance's state as it was
+	// last persisted under schemaVersion, forward to a value compatible with
+	// the provider's current schema. Providers that have never changed their
+	// schema version can implement this as a plain JSON decode; it exists as
+	// its own hook so that a provider whose schema has evolved gets a chance
+	// to translate old field shapes before core reads the result.
+	UpgradeState(info *InstanceInfo, rawJSON []byte, schemaVersion int) (*InstanceState, error)
+
+	// Resources returns the list of resource types this provider implements.
+	Resources() []ResourceType
+
+	// ReadDataDiff computes the diff for reading a data source.
+	ReadDataDiff(info *InstanceInfo, c *ResourceConfig) (*InstanceDiff, error)
+
+	// ReadDataApply reads a data source given the diff ReadDataDiff computed
+	// for it.
+	ReadDataApply(info *InstanceInfo, d *InstanceDiff) (*InstanceState, error)
+
+	// DataSources returns the list of data sources this provider implements.
+	DataSources() []DataSource
+
+	// Close releases any resources the provider is holding, such as a
+	// plugin subprocess and its connection. Terraform calls this at the end
+	// of every phase.
+	Close() error
+
+	// Stop asks the provider to abandon any in-flight operations as soon as
+	// it safely can.
+	Stop() error
+}
@@ -0,0 +1,28 @@
+package terraform
+
+// ResourceState holds the last-known state of a single resource, as
+// recorded the last time it was applied. It's attached to a
+// NodeAbstractResource via AttachResourceState so an operation can fall
+// back to it when a resource's config is no longer available, e.g.
+// because the resource was removed from config and is now only present
+// to be destroyed.
+type ResourceState struct {
+	// Provider is the absolute address of the provider configuration that
+	// last applied this resource, in its legacy string-traversal form
+	// (e.g. "provider.aws.west"). ProvidedBy parses it back into an
+	// addrs.AbsProviderConfig and migrates it to the current source-aware
+	// representation via LegacySource.
+	Provider string
+
+	// Dependencies lists the other resources this resource depended on,
+	// the last time it was applied, as legacy flattened strings like
+	// "aws_instance.foo" rather than the structured addrs.ConfigResource
+	// values StateDependencies reports. State predates the switch to
+	// structured dependency addresses, so nothing here upgrades this
+	// field in place; References already parses these strings the same
+	// way it would a config-derived reference, and StateDependencies
+	// builds on that, so callers that need the upgraded form should go
+	// through StateDependencies rather than reading Dependencies
+	// directly.
+	Dependencies []string
+}
@@ -0,0 +1,74 @@
+package terraform
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/dag"
+)
+
+// DestroyReferenceTransformer connects the destroy-time provisioner
+// references reported by GraphNodeDestroyReferencer to the vertices they
+// refer to, with their edges inverted relative to the ordinary
+// GraphNodeReferencer edges ReferenceTransformer adds.
+//
+// A destroy-time (when = destroy) provisioner needs the resources its
+// connection or config blocks refer to to still exist when it runs, which
+// is the opposite of the usual "depend on what you reference" ordering: if
+// resource A's destroy provisioner references resource B, A must be
+// destroyed before B is, so the edge runs from B to A rather than from A to
+// B. ReferenceTransformer itself only knows about the forward-ordering
+// GraphNodeReferencer interface, so this inversion needs its own pass;
+// ApplyGraphBuilder runs it immediately after ReferenceTransformer for that
+// reason.
+type DestroyReferenceTransformer struct{}
+
+func (t *DestroyReferenceTransformer) Transform(g *Graph) error {
+	vertices := g.Vertices()
+
+	referenceable := make(map[string]dag.Vertex)
+	for _, v := range vertices {
+		rn, ok := v.(GraphNodeReferenceable)
+		if !ok {
+			continue
+		}
+		for _, addr := range rn.ReferenceableAddrs() {
+			referenceable[addr.String()] = v
+		}
+	}
+
+	for _, v := range vertices {
+		dr, ok := v.(GraphNodeDestroyReferencer)
+		if !ok {
+			continue
+		}
+
+		for _, ref := range dr.DestroyReferences() {
+			var key string
+			switch s := ref.Subject.(type) {
+			case addrs.Resource:
+				key = s.String()
+			case addrs.ResourceInstance:
+				key = s.Resource.String()
+			default:
+				key = ref.Subject.String()
+			}
+
+			target, ok := referenceable[key]
+			if !ok {
+				log.Printf("[WARN] DestroyReferenceTransformer: %q references %q, which can't be found", dag.VertexName(v), key)
+				continue
+			}
+			if target == v {
+				continue
+			}
+
+			// Inverted relative to a normal reference edge: v must be
+			// destroyed before target, so target depends on v rather than
+			// the other way around.
+			g.Connect(dag.BasicEdge(target, v))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package terraform
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform/dag"
+)
+
+// GraphNodeAttachDestroyWave is implemented by nodes that can record which
+// destroy wave they've been assigned to. DestroyWaveTransformer calls
+// SetDestroyWave on every vertex that implements this interface (in
+// practice, NodePlanDestroyableResource); vertices that don't implement it
+// are left out of wave partitioning entirely, so something like the single
+// root node RootTransformer adds just runs on its own after every wave.
+type GraphNodeAttachDestroyWave interface {
+	SetDestroyWave(wave int)
+}
+
+// DestroyWaveTransformer partitions a destroy-plan graph into "waves": a
+// wave is a maximal antichain of GraphNodeAttachDestroyWave vertices that
+// have no remaining dependency on one another, so every vertex in a wave
+// can be evaluated concurrently once the previous wave has finished.
+//
+// Waves are numbered from 0 by repeatedly peeling off the subset of
+// not-yet-assigned vertices whose dependencies (DownEdges) have all already
+// been assigned to an earlier wave. Within a wave, assignment order doesn't
+// affect correctness, but candidates are still sorted by vertex name before
+// being assigned so that running the transformer twice against the same
+// graph shape always yields the same wave numbers for the same resources;
+// that determinism is what keeps plan output stable across runs.
+//
+// It's also why DestroyPlanGraphBuilder runs this transformer a second time
+// after TargetsTransformer has pruned the graph, rather than trying to
+// patch up the first pass's numbers in place: pruning can remove an entire
+// wave, and simply re-partitioning what's left is the easiest way to
+// guarantee the remaining waves are a dense 0..N sequence with no gaps for
+// progress reporting to trip over.
+type DestroyWaveTransformer struct{}
+
+func (t *DestroyWaveTransformer) Transform(g *Graph) error {
+	remaining := make(map[dag.Vertex]bool)
+	for _, v := range g.Vertices() {
+		if _, ok := v.(GraphNodeAttachDestroyWave); ok {
+			remaining[v] = true
+		}
+	}
+
+	for wave := 0; len(remaining) > 0; wave++ {
+		var ready []dag.Vertex
+		for v := range remaining {
+			blocked := false
+			for _, dep := range g.DownEdges(v).List() {
+				if remaining[dep] {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				ready = append(ready, v)
+			}
+		}
+
+		if len(ready) == 0 {
+			// A cycle among destroy-wave vertices would leave us unable to
+			// make progress; that's a graph validity problem for an earlier
+			// pass to catch; here we just stop rather than loop forever,
+			// leaving whatever's left unassigned.
+			break
+		}
+
+		sort.Slice(ready, func(i, j int) bool {
+			return dag.VertexName(ready[i]) < dag.VertexName(ready[j])
+		})
+
+		for _, v := range ready {
+			v.(GraphNodeAttachDestroyWave).SetDestroyWave(wave)
+			delete(remaining, v)
+		}
+	}
+
+	return nil
+}
@@ -7,18 +7,29 @@ import (
 
 	"github.com/hashicorp/terraform/addrs"
 	"github.com/hashicorp/hcl2/hcl"
-	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform/configs"
 	"github.com/hashicorp/terraform/dag"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
 )
 
-func TransformProviders(providers []string, concrete ConcreteProviderNodeFunc, config *configs.Config) GraphTransformer {
+func TransformProviders(providers []string, concrete ConcreteProviderNodeFunc, config *configs.Config, eval EvalContext) GraphTransformer {
 	return GraphTransformMulti(
 		// Add providers from the config
 		&ProviderConfigTransformer{
 			Config:    config,
 			Providers: providers,
 			Concrete:  concrete,
+			Eval:      eval,
+		},
+		// Expand any provider block that declares a set of aliases to
+		// fan out into, rather than a single fixed alias
+		&ProviderAliasExpansionTransformer{
+			Config:   config,
+			Concrete: concrete,
+			Eval:     eval,
 		},
 		// Add any remaining missing providers
 		&MissingProviderTransformer{
@@ -27,10 +38,15 @@ func TransformProviders(providers []string, concrete ConcreteProviderNodeFunc, c
 		},
 		// Connect the providers
 		&ProviderTransformer{},
+		// Fan out any provider with partitioned consumers into pooled
+		// instances
+		&ProviderInstanceTransformer{},
 		// Remove unused providers and proxies
 		&PruneProviderTransformer{},
 		// Connect provider to their parent provider nodes
 		&ParentProviderTransformer{},
+		// Check required_providers version constraints against what's left
+		&ProviderVersionConstraintTransformer{Config: config},
 	)
 }
 
@@ -70,6 +86,45 @@ type GraphNodeProviderConsumer interface {
 	SetProvider(addrs.AbsProviderConfig)
 }
 
+// GraphNodeProviderInstance is implemented by provider nodes that support
+// being fanned out into multiple independently-configured instances of the
+// same provider configuration, keyed by an arbitrary partition string (for
+// example a resource's "provider_instance" meta-argument, or a workspace
+// bucket). A node that doesn't implement this interface is treated as
+// having a single, un-partitioned instance.
+type GraphNodeProviderInstance interface {
+	GraphNodeProvider
+
+	// ProviderInstanceKey returns the partition key this node was created
+	// for. The base node added by ProviderConfigTransformer always reports
+	// the empty key.
+	ProviderInstanceKey() string
+
+	// WithProviderInstance returns a new node, configured the same way as
+	// the receiver but for the given non-empty partition key, to be added
+	// to the graph alongside the base provider node.
+	WithProviderInstance(key string) GraphNodeProviderInstance
+}
+
+// GraphNodeProviderInstanceConsumer is implemented by provider consumers
+// (typically resources) that want to pin themselves to a specific provider
+// instance partition instead of sharing the module's single configured
+// instance, e.g. via a "provider_instance" meta-argument.
+type GraphNodeProviderInstanceConsumer interface {
+	// ProviderInstanceKey returns the partition key this consumer should be
+	// connected to, if it has expressed one.
+	ProviderInstanceKey() (key string, ok bool)
+}
+
+// GraphNodeProviderConsumerSubject is implemented by provider consumers that
+// can report the source location of the config block that named their
+// desired provider (typically the resource block itself), so that
+// diagnostics about a missing or misconfigured provider can point back into
+// HCL instead of only naming the graph node.
+type GraphNodeProviderConsumerSubject interface {
+	ProviderConsumerSubjectRange() (hcl.Range, bool)
+}
+
 // ProviderTransformer is a GraphTransformer that maps resources to
 // providers within the graph. This will error if there are any resources
 // that don't map to proper resources.
@@ -77,58 +132,138 @@ type ProviderTransformer struct{}
 
 func (t *ProviderTransformer) Transform(g *Graph) error {
 	// Go through the other nodes and match them to providers they need
-	var err error
+	var diags tfdiags.Diagnostics
 	m := providerVertexMap(g)
 	for _, v := range g.Vertices() {
-		if pv, ok := v.(GraphNodeProviderConsumer); ok {
-			p, exact := pv.ProvidedBy()
-
-			key := p.String()
-			target := m[key]
-
-			sp, ok := pv.(GraphNodeSubPath)
-			if !ok && target == nil {
-				// no target, and no path to walk up
-				err = multierror.Append(err, fmt.Errorf(
-					"%s: provider %s couldn't be found",
-					dag.VertexName(v), p))
-				break
+		pv, ok := v.(GraphNodeProviderConsumer)
+		if !ok {
+			continue
+		}
+
+		p, exact := pv.ProvidedBy()
+		key := providerConfigKey(p)
+		target := m[key]
+
+		var subject *hcl.Range
+		if sr, ok := v.(GraphNodeProviderConsumerSubject); ok {
+			if rng, ok := sr.ProviderConsumerSubjectRange(); ok {
+				subject = &rng
 			}
+		}
 
-			// if we don't have a provider at this level, walk up the path looking for one,
-			// unless we were told to be exact.
-			if !exact {
-				for pp, ok := p.Inherited(); ok; pp, ok = pp.Inherited() {
-					key := pp.String()
-					target = m[key]
-					if target != nil {
-						break
-					}
+		if _, ok := v.(GraphNodeSubPath); !ok && target == nil {
+			// no target, and no path to walk up
+			diags = diags.Append(missingProviderDiagnostic(
+				v, p, subject,
+				fmt.Sprintf("provider %s couldn't be found", p), m,
+			))
+			continue
+		}
+
+		// if we don't have a provider at this level, walk up the path looking for one,
+		// unless we were told to be exact.
+		if !exact {
+			for pp, ok := p.Inherited(); ok; pp, ok = pp.Inherited() {
+				key = providerConfigKey(pp)
+				target = m[key]
+				if target != nil {
+					break
 				}
 			}
+		}
 
-			if target == nil {
-				err = multierror.Append(err, fmt.Errorf(
-					"%s: configuration for %s is not present; a provider configuration block is required for all operations",
-					dag.VertexName(v), p,
-				))
-				break
-			}
+		if target == nil {
+			diags = diags.Append(missingProviderDiagnostic(
+				v, p, subject,
+				fmt.Sprintf("configuration for %s is not present; a provider configuration block is required for all operations", p), m,
+			))
+			continue
+		}
 
-			// see if this in  an inherited provider
-			if p, ok := target.(*graphNodeProxyProvider); ok {
-				g.Remove(p)
-				target = p.Target()
-				key = target.(GraphNodeProvider).ProviderAddr().String()
-			}
+		// see if this in  an inherited provider
+		if proxy, ok := target.(*graphNodeProxyProvider); ok {
+			g.Remove(proxy)
+			target = proxy.Target()
+			key = target.(GraphNodeProvider).ProviderAddr().String()
+		}
+
+		log.Printf("[DEBUG] resource %s using provider %s", dag.VertexName(pv), key)
+		pv.SetProvider(target.ProviderAddr())
+		g.Connect(dag.BasicEdge(v, target))
+	}
+
+	return diags.Err()
+}
+
+// missingProviderDiagnostic builds the tfdiags.Diagnostic reported when a
+// provider consumer can't be matched to a provider node, including a
+// "did you mean" suggestion computed by Levenshtein distance against the
+// addresses actually present in the graph.
+func missingProviderDiagnostic(v dag.Vertex, p addrs.AbsProviderConfig, subject *hcl.Range, detail string, m map[string]GraphNodeProvider) *hcl.Diagnostic {
+	if suggestion := suggestProviderKey(providerConfigKey(p), m); suggestion != "" {
+		detail += fmt.Sprintf(" Did you mean %s?", suggestion)
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Provider configuration not found",
+		Detail:   fmt.Sprintf("%s: %s", dag.VertexName(v), detail),
+		Subject:  subject,
+	}
+}
 
-			log.Printf("[DEBUG] resource %s using provider %s", dag.VertexName(pv), key)
-			pv.SetProvider(target.ProviderAddr())
-			g.Connect(dag.BasicEdge(v, target))
+// suggestProviderKey returns the providerVertexMap key closest to want by
+// Levenshtein distance, or "" if none is close enough to be a useful
+// suggestion.
+func suggestProviderKey(want string, m map[string]GraphNodeProvider) string {
+	const maxDistance = 3 // arbitrary cutoff beyond which a suggestion isn't helpful
+
+	best := ""
+	bestDistance := maxDistance + 1
+	for key := range m {
+		d := levenshteinDistance(want, key)
+		if d < bestDistance {
+			best = key
+			bestDistance = d
 		}
 	}
+	if bestDistance > maxDistance {
+		return ""
+	}
+	return best
+}
 
-	return err
+// levenshteinDistance returns the classic edit distance between a and b.
+func levenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			del := prev[j] + 1
+			ins := curr[j-1] + 1
+			sub := prev[j-1] + cost
+			min := del
+			if ins < min {
+				min = ins
+			}
+			if sub < min {
+				min = sub
+			}
+			curr[j] = min
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
 }
 
 // CloseProviderTransformer is a GraphTransformer that adds nodes to the
@@ -144,14 +279,20 @@ func (t *CloseProviderTransformer) Transform(g *Graph) error {
 
 	for _, v := range pm {
 		p := v.(GraphNodeProvider)
-		key := p.ProviderAddr().String()
+		key := providerVertexKey(p)
 
-		// get the close provider of this type if we alread created it
+		// get the close provider of this type (and instance) if we already
+		// created it
 		closer := cpm[key]
 
 		if closer == nil {
-			// create a closer for this provider type
-			closer = &graphNodeCloseProvider{Addr: p.ProviderAddr()}
+			// create a closer for this provider type/instance
+			closer = &graphNodeCloseProvider{
+				Addr: p.ProviderAddr(),
+			}
+			if pi, ok := p.(GraphNodeProviderInstance); ok {
+				closer.InstanceKey = pi.ProviderInstanceKey()
+			}
 			g.Add(closer)
 			cpm[key] = closer
 		}
@@ -203,7 +344,7 @@ func (t *MissingProviderTransformer) Transform(g *Graph) error {
 
 		p, _ := pv.ProvidedBy()
 		configAddr := p.ProviderConfig
-		key := configAddr.String()
+		key := configAddr.LegacySource().String()
 		provider := m[key]
 
 		// we already have it
@@ -258,7 +399,7 @@ func (t *ParentProviderTransformer) Transform(g *Graph) error {
 		addr := pn.ProviderAddr()
 		parentAddr, ok := addr.Inherited()
 		if ok {
-			parent := pm[parentAddr.String()]
+			parent := pm[providerConfigKey(parentAddr)]
 			if parent != nil {
 				g.Connect(dag.BasicEdge(v, parent))
 			}
@@ -267,6 +408,173 @@ func (t *ParentProviderTransformer) Transform(g *Graph) error {
 	return nil
 }
 
+// GraphNodeAttachProviderVersion is implemented by provider nodes that can
+// accept the intersected required_providers version constraint resolved for
+// their address, so that later steps (such as plugin selection) can read it
+// back off the node instead of re-walking the configuration tree.
+type GraphNodeAttachProviderVersion interface {
+	AttachProviderVersionConstraint(version.Constraints)
+}
+
+// GraphNodeProviderVersion is implemented by provider nodes that already
+// know which version of their provider they've resolved to, e.g. once a
+// plugin has been selected. It's optional: a provider node that doesn't
+// implement it still gets its constraint attached via
+// GraphNodeAttachProviderVersion, but isn't checked against it here.
+type GraphNodeProviderVersion interface {
+	ProviderVersion() (ver string, known bool)
+}
+
+// providerVersionRequirement is the result of intersecting every module's
+// required_providers constraint for a single provider type.
+type providerVersionRequirement struct {
+	constraints version.Constraints
+	modulePaths []string
+}
+
+// ProviderVersionConstraintTransformer walks the configuration tree
+// collecting each module's required_providers version constraints,
+// intersects them per provider type, attaches the combined constraint to
+// every GraphNodeProvider of that type in the graph, and reports a diagnostic if a
+// provider has already resolved to a version the constraint rules out.
+//
+// Detecting a conflict between two modules' constraints in general requires
+// reasoning about arbitrary version ranges, which this transformer doesn't
+// attempt; it only catches the common case of two modules pinning the same
+// provider to different exact versions via "= x.y.z".
+type ProviderVersionConstraintTransformer struct {
+	Config *configs.Config
+}
+
+func (t *ProviderVersionConstraintTransformer) Transform(g *Graph) error {
+	if t.Config == nil {
+		return nil
+	}
+
+	reqs := make(map[string]*providerVersionRequirement)
+	if err := t.gather(t.Config, reqs); err != nil {
+		return err
+	}
+
+	var diags tfdiags.Diagnostics
+	for _, v := range g.Vertices() {
+		provider, ok := v.(GraphNodeProvider)
+		if !ok {
+			continue
+		}
+
+		addr := provider.ProviderAddr()
+		key := providerRequirementKey(addr.ProviderConfig.Type, nil)
+		req, ok := reqs[key]
+		if !ok {
+			continue
+		}
+
+		if apv, ok := provider.(GraphNodeAttachProviderVersion); ok {
+			apv.AttachProviderVersionConstraint(req.constraints)
+		}
+
+		pv, ok := provider.(GraphNodeProviderVersion)
+		if !ok {
+			continue
+		}
+		current, known := pv.ProviderVersion()
+		if !known {
+			continue
+		}
+
+		cv, err := version.NewVersion(current)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid provider version",
+				fmt.Sprintf("Provider %s reports version %q, which is not a valid version number.", dag.VertexName(v), current),
+			))
+			continue
+		}
+		if !req.constraints.Check(cv) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Provider version constraint not satisfied",
+				fmt.Sprintf(
+					"Provider %s is configured at version %s, which does not satisfy the version constraint %s required by %s.",
+					dag.VertexName(v), cv, req.constraints, strings.Join(req.modulePaths, ", "),
+				),
+			))
+		}
+	}
+
+	return diags.Err()
+}
+
+// gather recurses over the configuration tree, intersecting each module's
+// required_providers constraint into reqs, keyed by providerRequirementKey.
+func (t *ProviderVersionConstraintTransformer) gather(c *configs.Config, reqs map[string]*providerVersionRequirement) error {
+	if c == nil {
+		return nil
+	}
+
+	for name, rp := range c.Module.RequiredProviders {
+		if rp == nil || rp.Requirement.Required == nil {
+			continue
+		}
+
+		key := providerRequirementKey(name, nil)
+		if existing, ok := reqs[key]; ok {
+			if v1, ok1 := exactVersion(existing.constraints); ok1 {
+				if v2, ok2 := exactVersion(rp.Requirement.Required); ok2 && v1 != v2 {
+					return fmt.Errorf(
+						"provider %q: conflicting version constraints: %s (required by %s) and %s (required by %s)",
+						name, v1, strings.Join(existing.modulePaths, ", "), v2, c.Path,
+					)
+				}
+			}
+			existing.constraints = append(existing.constraints, rp.Requirement.Required...)
+			existing.modulePaths = append(existing.modulePaths, c.Path.String())
+		} else {
+			reqs[key] = &providerVersionRequirement{
+				constraints: rp.Requirement.Required,
+				modulePaths: []string{c.Path.String()},
+			}
+		}
+	}
+
+	for _, cc := range c.Children {
+		if err := t.gather(cc, reqs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exactVersion returns the version a constraint set pins to via a lone "="
+// constraint, if it has exactly one constraint and it's of that form.
+func exactVersion(cs version.Constraints) (string, bool) {
+	if len(cs) != 1 {
+		return "", false
+	}
+	s := strings.TrimSpace(cs[0].String())
+	if !strings.HasPrefix(s, "= ") {
+		return "", false
+	}
+	return strings.TrimPrefix(s, "= "), true
+}
+
+// staticModulePath extracts the sequence of module call names from a dynamic
+// module instance address, discarding any instance keys; required_providers
+// constraints are declared per static module, not per instance.
+func staticModulePath(mi addrs.ModuleInstance) []string {
+	names := make([]string, len(mi))
+	for i, step := range mi {
+		names[i] = step.Name
+	}
+	return names
+}
+
+func providerRequirementKey(typeName string, modulePath []string) string {
+	return strings.Join(modulePath, "/") + ":" + typeName
+}
+
 // PruneProviderTransformer removes any providers that are not actually used by
 // anything, and provider proxies. This avoids the provider being initialized
 // and configured.  This both saves resources but also avoids errors since
@@ -297,12 +605,139 @@ func (t *PruneProviderTransformer) Transform(g *Graph) error {
 	return nil
 }
 
+// ProviderInstanceTransformer fans out a provider node that implements
+// GraphNodeProviderInstance into one configured instance per distinct
+// partition key requested by a GraphNodeProviderInstanceConsumer among its
+// dependents, repointing each such consumer at its own instance instead of
+// the single shared one ProviderConfigTransformer added. Provider addresses
+// with no partitioned consumers, or whose node doesn't implement
+// GraphNodeProviderInstance, are left exactly as ProviderTransformer
+// connected them.
+//
+// This only handles the graph-shape side of instance pooling: wiring the
+// instance's selection through to EvalContext.Provider/InitProvider/
+// CloseProvider so each instance gets its own live provider client is the
+// responsibility of whatever EvalNode construction derives a provider name
+// from a GraphNodeProvider's Name(); WithProviderInstance implementations
+// are expected to fold their key into Name() so that already-existing
+// per-name provider caching picks them up as distinct providers.
+type ProviderInstanceTransformer struct{}
+
+func (t *ProviderInstanceTransformer) Transform(g *Graph) error {
+	pm := providerVertexMap(g)
+
+	// Find every distinct partition key requested for each base provider
+	// address.
+	keysByProvider := make(map[string]map[string]bool)
+	for _, v := range g.Vertices() {
+		consumer, ok := v.(GraphNodeProviderConsumer)
+		if !ok {
+			continue
+		}
+		partitioned, ok := v.(GraphNodeProviderInstanceConsumer)
+		if !ok {
+			continue
+		}
+		key, ok := partitioned.ProviderInstanceKey()
+		if !ok || key == "" {
+			continue
+		}
+
+		addr, _ := consumer.ProvidedBy()
+		baseKey := providerConfigKey(addr)
+		if keysByProvider[baseKey] == nil {
+			keysByProvider[baseKey] = make(map[string]bool)
+		}
+		keysByProvider[baseKey][key] = true
+	}
+
+	for baseKey, keys := range keysByProvider {
+		base, ok := pm[baseKey]
+		if !ok {
+			continue
+		}
+		instanceable, ok := base.(GraphNodeProviderInstance)
+		if !ok {
+			// This provider type doesn't support instancing; partitioned
+			// consumers simply keep sharing the one base instance.
+			continue
+		}
+
+		instances := make(map[string]GraphNodeProviderInstance, len(keys))
+		for key := range keys {
+			inst := instanceable.WithProviderInstance(key)
+			g.Add(inst)
+			instances[key] = inst
+		}
+
+		// Repoint each partitioned consumer of this provider at its
+		// instance instead of the shared base node.
+		for _, v := range g.Vertices() {
+			consumer, ok := v.(GraphNodeProviderConsumer)
+			if !ok {
+				continue
+			}
+			partitioned, ok := v.(GraphNodeProviderInstanceConsumer)
+			if !ok {
+				continue
+			}
+			key, ok := partitioned.ProviderInstanceKey()
+			if !ok || key == "" {
+				continue
+			}
+			addr, _ := consumer.ProvidedBy()
+			if providerConfigKey(addr) != baseKey {
+				continue
+			}
+
+			inst := instances[key]
+			g.RemoveEdge(dag.BasicEdge(v, base))
+			g.Connect(dag.BasicEdge(v, inst))
+			consumer.SetProvider(inst.ProviderAddr())
+		}
+	}
+
+	return nil
+}
+
+// providerInstanceKeySuffix, if v is a GraphNodeProviderInstance reporting a
+// non-empty partition key, returns the suffix that distinguishes it from its
+// siblings sharing the same provider address; otherwise "".
+func providerInstanceKeySuffix(v GraphNodeProvider) string {
+	if pi, ok := v.(GraphNodeProviderInstance); ok {
+		if key := pi.ProviderInstanceKey(); key != "" {
+			return "#" + key
+		}
+	}
+	return ""
+}
+
+// providerConfigKey returns the map key an AbsProviderConfig normalizes to
+// for provider-matching purposes. Namespace and Hostname are filled in with
+// their implied defaults (via LegacySource) before the address is
+// stringified, so two provider configurations that both omit an explicit
+// source still collide exactly as they always have (both normalize to the
+// same implied registry.terraform.io/hashicorp default), while one that
+// explicitly names a different source no longer collides with one that
+// doesn't, even though they share the same short type name.
+func providerConfigKey(addr addrs.AbsProviderConfig) string {
+	addr.ProviderConfig = addr.ProviderConfig.LegacySource()
+	return addr.String()
+}
+
+// providerVertexKey returns the map key used to look up a provider node by
+// its address, disambiguated by provider instance partition key (see
+// GraphNodeProviderInstance) so that multiple configured instances of the
+// same provider address can coexist in providerVertexMap.
+func providerVertexKey(v GraphNodeProvider) string {
+	return providerConfigKey(v.ProviderAddr()) + providerInstanceKeySuffix(v)
+}
+
 func providerVertexMap(g *Graph) map[string]GraphNodeProvider {
 	m := make(map[string]GraphNodeProvider)
 	for _, v := range g.Vertices() {
 		if pv, ok := v.(GraphNodeProvider); ok {
-			addr := pv.ProviderAddr()
-			m[addr.String()] = pv
+			m[providerVertexKey(pv)] = pv
 		}
 	}
 
@@ -313,8 +748,11 @@ func closeProviderVertexMap(g *Graph) map[string]GraphNodeCloseProvider {
 	m := make(map[string]GraphNodeCloseProvider)
 	for _, v := range g.Vertices() {
 		if pv, ok := v.(GraphNodeCloseProvider); ok {
-			addr := pv.CloseProviderAddr()
-			m[addr.String()] = pv
+			key := providerConfigKey(pv.CloseProviderAddr())
+			if cpi, ok := pv.(*graphNodeCloseProvider); ok && cpi.InstanceKey != "" {
+				key += "#" + cpi.InstanceKey
+			}
+			m[key] = pv
 		}
 	}
 
@@ -323,6 +761,11 @@ func closeProviderVertexMap(g *Graph) map[string]GraphNodeCloseProvider {
 
 type graphNodeCloseProvider struct {
 	Addr addrs.AbsProviderConfig
+
+	// InstanceKey, if set, identifies which configured instance of Addr
+	// (see GraphNodeProviderInstance) this closer is responsible for. It's
+	// empty for the ordinary, non-pooled case.
+	InstanceKey string
 }
 
 var (
@@ -330,7 +773,11 @@ var (
 )
 
 func (n *graphNodeCloseProvider) Name() string {
-	return n.Addr.String() + " (close)"
+	name := n.Addr.String() + " (close)"
+	if n.InstanceKey != "" {
+		name += " (instance: " + n.InstanceKey + ")"
+	}
+	return name
 }
 
 // GraphNodeSubPath impl.
@@ -407,6 +854,131 @@ func (n *graphNodeProxyProvider) Target() GraphNodeProvider {
 	}
 }
 
+// ProviderResolver is implemented by anything that can resolve the provider
+// configuration a resource should use when neither its own config nor its
+// state records one exactly. Implementations might add alias inheritance
+// from parent modules, fuzzy matching by resource type prefix, or consult a
+// required_providers block, in place of NodeAbstractResource's default
+// behavior of guessing a default provider configuration from the resource's
+// type name.
+type ProviderResolver interface {
+	ResolveProvider(n *NodeAbstractResource) (addrs.AbsProviderConfig, bool)
+}
+
+// GraphNodeAttachProviderResolver is implemented by nodes that accept an
+// injected ProviderResolver.
+type GraphNodeAttachProviderResolver interface {
+	SetProviderResolver(ProviderResolver)
+}
+
+// AttachProviderResolverTransformer attaches a single ProviderResolver to
+// every node in the graph that accepts one, so that the CLI or a test
+// harness can override implicit provider resolution without
+// NodeAbstractResource needing to know about any particular strategy.
+type AttachProviderResolverTransformer struct {
+	Resolver ProviderResolver
+}
+
+func (t *AttachProviderResolverTransformer) Transform(g *Graph) error {
+	if t.Resolver == nil {
+		return nil
+	}
+	for _, v := range g.Vertices() {
+		if arn, ok := v.(GraphNodeAttachProviderResolver); ok {
+			arn.SetProviderResolver(t.Resolver)
+		}
+	}
+	return nil
+}
+
+// ProviderAliasExpansionTransformer expands a provider configuration block
+// that declares a "for_each" set of alias names into one provider node per
+// element, replacing the single placeholder node that ProviderConfigTransformer
+// added for it. This lets a module fan a provider out across many aliases
+// (for example, one per region) without a hand-written provider block per
+// alias.
+type ProviderAliasExpansionTransformer struct {
+	Config   *configs.Config
+	Concrete ConcreteProviderNodeFunc
+
+	// Eval is used to evaluate each provider's "for_each" expression to the
+	// set of alias names it should expand into. If nil, no provider blocks
+	// are treated as having a "for_each", so this transformer is a no-op.
+	Eval EvalContext
+}
+
+func (t *ProviderAliasExpansionTransformer) Transform(g *Graph) error {
+	if t.Config == nil || t.Eval == nil {
+		return nil
+	}
+	return t.transform(g, t.Config)
+}
+
+func (t *ProviderAliasExpansionTransformer) transform(g *Graph, c *configs.Config) error {
+	if c == nil {
+		return nil
+	}
+	if err := t.transformSingle(g, c); err != nil {
+		return err
+	}
+	for _, cc := range c.Children {
+		if err := t.transform(g, cc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *ProviderAliasExpansionTransformer) transformSingle(g *Graph, c *configs.Config) error {
+	staticPath := c.Path
+	path := make(addrs.ModuleInstance, len(staticPath))
+	for i, name := range staticPath {
+		path[i] = addrs.ModuleInstanceStep{
+			Name: name,
+		}
+	}
+
+	pm := providerVertexMap(g)
+
+	for _, p := range c.Module.ProviderConfigs {
+		if p.ForEach == nil {
+			// A regular, single-aliased (or un-aliased) provider block;
+			// ProviderConfigTransformer already added its node.
+			continue
+		}
+
+		baseAddr := p.Addr().Absolute(path)
+		placeholder := pm[providerConfigKey(baseAddr)]
+
+		aliases, diags := t.Eval.EvaluateExpr(p.ForEach, cty.Set(cty.String), nil)
+		if diags.HasErrors() {
+			return diags.Err()
+		}
+		if aliases.IsNull() || !aliases.IsWhollyKnown() {
+			return fmt.Errorf("%s: for_each set must be a known value", baseAddr)
+		}
+
+		if placeholder != nil {
+			g.Remove(placeholder)
+			delete(pm, providerConfigKey(baseAddr))
+		}
+
+		for it := aliases.ElementIterator(); it.Next(); {
+			_, aliasVal := it.Element()
+			addr := baseAddr
+			addr.ProviderConfig.Alias = aliasVal.AsString()
+
+			v := t.Concrete(&NodeAbstractProvider{
+				Addr: addr,
+			})
+			g.Add(v)
+			pm[providerConfigKey(addr)] = v.(GraphNodeProvider)
+		}
+	}
+
+	return nil
+}
+
 // ProviderConfigTransformer adds all provider nodes from the configuration and
 // attaches the configs.
 type ProviderConfigTransformer struct {
@@ -421,6 +993,14 @@ type ProviderConfigTransformer struct {
 
 	// Config is the root node of the configuration tree to add providers from.
 	Config *configs.Config
+
+	// Eval is used to evaluate the "count" and "for_each" arguments of module
+	// calls so that a module instantiated multiple times gets one provider
+	// (and one set of proxies) per instance key, rather than a single shared
+	// set keyed as if the module had no instance key at all. If nil, every
+	// module call is treated as having exactly one, keyless instance, which
+	// is only correct for module calls without "count" or "for_each".
+	Eval EvalContext
 }
 
 func (t *ProviderConfigTransformer) Transform(g *Graph) error {
@@ -442,43 +1022,93 @@ func (t *ProviderConfigTransformer) Transform(g *Graph) error {
 }
 
 func (t *ProviderConfigTransformer) transform(g *Graph, c *configs.Config) error {
+	return t.transformAt(g, c, addrs.RootModuleInstance)
+}
+
+// transformAt adds the providers declared directly in c, and then recurses
+// into c's children once per instance key implied by their "count" or
+// "for_each" arguments, so that a module called multiple times gets its own
+// provider (and proxy) nodes under each resulting path.
+func (t *ProviderConfigTransformer) transformAt(g *Graph, c *configs.Config, path addrs.ModuleInstance) error {
 	// If no config, do nothing
 	if c == nil {
 		return nil
 	}
 
 	// Add our resources
-	if err := t.transformSingle(g, c); err != nil {
+	if err := t.transformSingle(g, c, path); err != nil {
 		return err
 	}
 
-	// Transform all the children.
-	for _, cc := range c.Children {
-		if err := t.transform(g, cc); err != nil {
+	// Transform all the children, once per instance key of the call to each.
+	for name, cc := range c.Children {
+		keys, err := t.instanceKeys(c.Module.ModuleCalls[name])
+		if err != nil {
 			return err
 		}
+		for _, key := range keys {
+			if err := t.transformAt(g, cc, path.Child(name, key)); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
-func (t *ProviderConfigTransformer) transformSingle(g *Graph, c *configs.Config) error {
-	log.Printf("[TRACE] ProviderConfigTransformer: Starting for module %q", c.Path.String())
-
-	// Get the module associated with this configuration tree node
-	mod := c.Module
-	staticPath := c.Path
+// instanceKeys returns the instance keys implied by a module call's "count"
+// or "for_each" argument. A call with neither, or a nil call (the root
+// module has no enclosing call), expands to a single instance with no key.
+// If t.Eval is nil we can't evaluate these arguments, so we conservatively
+// fall back to the same single keyless instance; this keeps modules without
+// "count"/"for_each" working even when no EvalContext is available yet.
+func (t *ProviderConfigTransformer) instanceKeys(call *configs.ModuleCall) ([]addrs.InstanceKey, error) {
+	switch {
+	case call == nil || t.Eval == nil:
+		return []addrs.InstanceKey{nil}, nil
+
+	case call.Count != nil:
+		val, diags := t.Eval.EvaluateExpr(call.Count, cty.Number, nil)
+		if diags.HasErrors() {
+			return nil, diags.Err()
+		}
+		if !val.IsWhollyKnown() {
+			return nil, fmt.Errorf("module %s: count must be known to expand provider configurations", call.Name)
+		}
+		var n int
+		if err := gocty.FromCtyValue(val, &n); err != nil {
+			return nil, fmt.Errorf("module %s: invalid count value: %s", call.Name, err)
+		}
+		keys := make([]addrs.InstanceKey, n)
+		for i := range keys {
+			keys[i] = addrs.IntKey(i)
+		}
+		return keys, nil
 
-	// We actually need a dynamic module path here, but we've not yet updated
-	// our graph builders enough to support expansion of module calls with
-	// "count" and "for_each" set, so for now we'll shim this by converting to
-	// a dynamic path with no keys. At the time of writing this is the only
-	// possible kind of dynamic path anyway.
-	path := make(addrs.ModuleInstance, len(staticPath))
-	for i, name := range staticPath {
-		path[i] = addrs.ModuleInstanceStep{
-			Name: name,
+	case call.ForEach != nil:
+		val, diags := t.Eval.EvaluateExpr(call.ForEach, cty.DynamicPseudoType, nil)
+		if diags.HasErrors() {
+			return nil, diags.Err()
 		}
+		if !val.IsWhollyKnown() {
+			return nil, fmt.Errorf("module %s: for_each must be known to expand provider configurations", call.Name)
+		}
+		var keys []addrs.InstanceKey
+		for it := val.ElementIterator(); it.Next(); {
+			k, _ := it.Element()
+			keys = append(keys, addrs.StringKey(k.AsString()))
+		}
+		return keys, nil
+
+	default:
+		return []addrs.InstanceKey{nil}, nil
 	}
+}
+
+func (t *ProviderConfigTransformer) transformSingle(g *Graph, c *configs.Config, path addrs.ModuleInstance) error {
+	log.Printf("[TRACE] ProviderConfigTransformer: Starting for module %q", path.String())
+
+	// Get the module associated with this configuration tree node
+	mod := c.Module
 
 	// add all providers from the configuration
 	for _, p := range mod.ProviderConfigs {
@@ -486,6 +1116,21 @@ func (t *ProviderConfigTransformer) transformSingle(g *Graph, c *configs.Config)
 		relAddr := p.Addr()
 		addr := relAddr.Absolute(path)
 
+		// If this provider has an explicit source pinned via
+		// required_providers, fold its namespace and hostname into the
+		// node's address so that it's treated as a distinct provider from
+		// any other configuration that happens to share the same short
+		// type name but not the same source (see providerConfigKey).
+		if rp := mod.RequiredProviders[name]; rp != nil && rp.Source != "" {
+			sourced, err := addrs.ProviderConfigForSource(rp.Source)
+			if err != nil {
+				log.Printf("[WARN] ProviderConfigTransformer: %s has invalid required_providers source %q: %s", name, rp.Source, err)
+			} else {
+				sourced.Alias = addr.ProviderConfig.Alias
+				addr.ProviderConfig = sourced
+			}
+		}
+
 		v := t.Concrete(&NodeAbstractProvider{
 			Addr: addr,
 		})
@@ -507,22 +1152,23 @@ func (t *ProviderConfigTransformer) transformSingle(g *Graph, c *configs.Config)
 	// Now replace the provider nodes with proxy nodes if a provider was being
 	// passed in, and create implicit proxies if there was no config. Any extra
 	// proxies will be removed in the prune step.
-	return t.addProxyProviders(g, c)
+	return t.addProxyProviders(g, c, path)
 }
 
-func (t *ProviderConfigTransformer) addProxyProviders(g *Graph, c *configs.Config) error {
-	path := c.Path
+func (t *ProviderConfigTransformer) addProxyProviders(g *Graph, c *configs.Config, path addrs.ModuleInstance) error {
+	staticPath := c.Path
 
 	// can't add proxies at the root
-	if len(path) == 0 {
+	if len(staticPath) == 0 {
 		return nil
 	}
 
-	parentPath, callName := path[:len(path)-1], path[len(path)-1]
-	parent := c.Descendent(parentPath)
+	parentStaticPath, callName := staticPath[:len(staticPath)-1], staticPath[len(staticPath)-1]
+	parent := c.Descendent(parentStaticPath)
 	if parent == nil {
 		return nil
 	}
+	parentPath := path[:len(path)-1]
 
 	var parentCfg *configs.ModuleCall
 	for name, mod := range parent.Module.ModuleCalls {
@@ -534,7 +1180,7 @@ func (t *ProviderConfigTransformer) addProxyProviders(g *Graph, c *configs.Confi
 
 	if parentCfg == nil {
 		// this can't really happen during normal execution.
-		return fmt.Errorf("parent module config not found for %s", m.Name())
+		return fmt.Errorf("parent module config not found for %s", callName)
 	}
 
 	// Go through all the providers the parent is passing in, and add proxies to
@@ -550,8 +1196,9 @@ func (t *ProviderConfigTransformer) addProxyProviders(g *Graph, c *configs.Confi
 		}
 
 		proxy := &graphNodeProxyProvider{
+			addr:      providerNameAddr(name).Absolute(path),
 			nameValue: name,
-			path:      path,
+			path:      staticPath,
 			target:    parentProvider,
 		}
 
@@ -577,6 +1224,18 @@ func (t *ProviderConfigTransformer) addProxyProviders(g *Graph, c *configs.Confi
 	return nil
 }
 
+// providerNameAddr turns a provider name as found in a module call's
+// "providers" map ("aws" or, for an aliased passthrough, "aws.east") into
+// the relative address it names.
+func providerNameAddr(name string) addrs.ProviderConfig {
+	typeName := name
+	alias := ""
+	if dot := strings.IndexByte(name, '.'); dot >= 0 {
+		typeName, alias = name[:dot], name[dot+1:]
+	}
+	return addrs.ProviderConfig{Type: typeName, Alias: alias}
+}
+
 func (t *ProviderConfigTransformer) attachProviderConfigs(g *Graph) error {
 	for _, v := range g.Vertices() {
 		// Only care about GraphNodeAttachProvider implementations
@@ -0,0 +1,62 @@
+package terraform
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/dag"
+)
+
+// GraphNodeStateDependencies is implemented by nodes that can report the
+// dependencies recorded against them in state, e.g. via
+// NodeAbstractResource.StateDependencies.
+type GraphNodeStateDependencies interface {
+	GraphNodeResource
+	StateDependencies() []addrs.ConfigResource
+}
+
+// StateDependencyTransformer connects each resource to the other resources
+// named by its StateDependencies, matched by resource address, so that it is
+// evaluated after them. StateDependencies is derived from References, which
+// for a resource whose config is gone falls back to the dependencies
+// recorded in state.
+//
+// ApplyGraphBuilder runs this after ReferenceTransformer; connecting a pair
+// that ReferenceTransformer already connected is a no-op.
+type StateDependencyTransformer struct{}
+
+func (t *StateDependencyTransformer) Transform(g *Graph) error {
+	vertices := g.Vertices()
+
+	resources := make(map[string]dag.Vertex)
+	for _, v := range vertices {
+		rn, ok := v.(GraphNodeResource)
+		if !ok {
+			continue
+		}
+		resources[rn.ResourceAddr().String()] = v
+	}
+
+	for _, v := range vertices {
+		sd, ok := v.(GraphNodeStateDependencies)
+		if !ok {
+			continue
+		}
+
+		for _, dep := range sd.StateDependencies() {
+			key := dep.String()
+			target, ok := resources[key]
+			if !ok {
+				log.Printf("[WARN] StateDependencyTransformer: %q depends on %q in state, which can't be found", dag.VertexName(v), key)
+				continue
+			}
+			if target == v {
+				continue
+			}
+
+			g.Connect(dag.BasicEdge(v, target))
+		}
+	}
+
+	return nil
+}